@@ -0,0 +1,90 @@
+package main
+
+import "fmt"
+
+// defaultMaxTxVsize 是未配置 maxTxVsize 时每笔交易的虚拟大小上限，低于标准交易上限 100000 vB 并留出余量
+const defaultMaxTxVsize = 99000
+
+// defaultEstimatedInputs 是未配置 estimatedInputs 时估算交易大小所用的输入数
+const defaultEstimatedInputs = 10
+
+// 估算交易大小所用的各部分大小，钱包的输入和找零按 P2WPKH 计算
+const (
+	p2wpkhInputWeight  = 41*4 + 108 // outpoint、nSequence 和空 scriptSig，加见证中的签名和公钥
+	p2wpkhScriptSize   = 22
+	defaultScriptSize  = 34 // 不知道脚本类型时按 P2WSH/P2TR 这样最长的常见脚本估算
+	txOverheadWeight   = 8*4 + 2
+	maxCompactSizeByte = 0xfc
+)
+
+// payoutBatch 是一笔 sendmany 交易付款的收款地址
+type payoutBatch struct {
+	Index      int
+	Recipients []Recipient
+	VSize      int // 估算的交易虚拟大小
+}
+
+// amounts 返回 sendmany 的 地址 -> 金额 参数
+func (b *payoutBatch) amounts() map[string]float64 {
+	amounts := make(map[string]float64, len(b.Recipients))
+	for _, r := range b.Recipients {
+		amounts[r.Address] = r.Amount
+	}
+	return amounts
+}
+
+// compactSize 返回 CompactSize 编码 n 所需的字节数
+func compactSize(n int) int {
+	switch {
+	case n <= maxCompactSizeByte:
+		return 1
+	case n <= 0xffff:
+		return 3
+	default:
+		return 5
+	}
+}
+
+// outputSize 返回付款给 r 的输出的字节数：金额、脚本长度和脚本
+func (r Recipient) outputSize() int {
+	script := r.scriptSize
+	if script == 0 {
+		script = defaultScriptSize
+	}
+	return 8 + compactSize(script) + script
+}
+
+// estimateVSize 估算有 inputs 个 P2WPKH 输入、outputs 个输出（合计 outputBytes 字节）的交易虚拟大小
+func estimateVSize(inputs, outputs, outputBytes int) int {
+	weight := txOverheadWeight + 4*(compactSize(inputs)+compactSize(outputs)+outputBytes) + inputs*p2wpkhInputWeight
+	return (weight + 3) / 4
+}
+
+// splitRecipients 按文件顺序把 recipients 分成多笔交易，每笔交易按 inputs 个输入和一个找零输出估算，
+// 虚拟大小不超过 maxVSize
+func splitRecipients(recipients []Recipient, maxVSize, inputs int) ([]payoutBatch, error) {
+	change := 8 + compactSize(p2wpkhScriptSize) + p2wpkhScriptSize
+	var batches []payoutBatch
+	current := payoutBatch{}
+	outputBytes := change
+	for _, r := range recipients {
+		size := r.outputSize()
+		vsize := estimateVSize(inputs, len(current.Recipients)+2, outputBytes+size)
+		if vsize > maxVSize && len(current.Recipients) > 0 {
+			batches = append(batches, current)
+			current = payoutBatch{Index: len(batches)}
+			outputBytes = change
+			vsize = estimateVSize(inputs, 2, outputBytes+size)
+		}
+		if vsize > maxVSize {
+			return nil, fmt.Errorf("a transaction paying %s with %d inputs is %d vB, over the %d vB limit", r.Address, inputs, vsize, maxVSize)
+		}
+		current.Recipients = append(current.Recipients, r)
+		current.VSize = vsize
+		outputBytes += size
+	}
+	if len(current.Recipients) > 0 {
+		batches = append(batches, current)
+	}
+	return batches, nil
+}
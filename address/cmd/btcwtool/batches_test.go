@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEstimateVSize(t *testing.T) {
+	// 1 个 P2WPKH 输入、2 个 P2WPKH 输出的交易为 140.5 vB
+	if got := estimateVSize(1, 2, 2*31); got != 141 {
+		t.Errorf("estimateVSize(1, 2) = %d, want 141", got)
+	}
+	// 输出数超过 252 时数量用 3 字节编码
+	if got, want := estimateVSize(1, 253, 253*31), estimateVSize(1, 252, 253*31)+2; got != want {
+		t.Errorf("estimateVSize(1, 253) = %d, want %d", got, want)
+	}
+}
+
+func TestSplitRecipients(t *testing.T) {
+	var recipients []Recipient
+	for i := 0; i < 10; i++ {
+		recipients = append(recipients, Recipient{Address: fmt.Sprintf("addr%d", i), Amount: 1, scriptSize: 22})
+	}
+	// P2TR 输出更大
+	recipients[9].scriptSize = 34
+	maxVSize := estimateVSize(2, 5, 5*31)
+	batches, err := splitRecipients(recipients, maxVSize, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 3 {
+		t.Fatalf("got %d batches, want 3", len(batches))
+	}
+	paid := 0
+	for i, b := range batches {
+		if b.Index != i || b.VSize > maxVSize {
+			t.Errorf("batch %d: index %d, vsize %d over %d", i, b.Index, b.VSize, maxVSize)
+		}
+		for _, r := range b.Recipients {
+			if r.Address != recipients[paid].Address {
+				t.Errorf("batch %d pays %s, want %s", i, r.Address, recipients[paid].Address)
+			}
+			paid++
+		}
+	}
+	if paid != len(recipients) || len(batches[0].Recipients) != 4 || len(batches[2].Recipients) != 2 {
+		t.Errorf("batch sizes %d, %d, %d", len(batches[0].Recipients), len(batches[1].Recipients), len(batches[2].Recipients))
+	}
+
+	if _, err := splitRecipients(recipients, 100, 2); err == nil {
+		t.Error("want error when a single recipient does not fit")
+	}
+}
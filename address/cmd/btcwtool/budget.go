@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// defaultBudgetWindow 是未设置 budgetWindowHours 时的滚动预算窗口
+const defaultBudgetWindow = 24 * time.Hour
+
+// feeSpend 是一次提高费率额外花费的手续费
+type feeSpend struct {
+	Time   time.Time `json:"time"`
+	Wallet string    `json:"wallet"`
+	TxID   string    `json:"txid"`
+	Amount float64   `json:"amount"` // BTCW
+}
+
+func (b *bumper) budgetWindow() time.Duration {
+	if b.config.BudgetWindowHours > 0 {
+		return time.Duration(b.config.BudgetWindowHours) * time.Hour
+	}
+	return defaultBudgetWindow
+}
+
+// spent 返回滚动窗口内钱包 walletName 和全部钱包额外花费的手续费，并丢弃窗口外的记录
+func (b *bumper) spent(walletName string) (wallet, total float64) {
+	since := b.now().Add(-b.budgetWindow())
+	var kept []*feeSpend
+	for _, s := range b.spends {
+		if s.Time.Before(since) {
+			continue
+		}
+		kept = append(kept, s)
+		total += s.Amount
+		if s.Wallet == walletName {
+			wallet += s.Amount
+		}
+	}
+	b.spends = kept
+	return wallet, total
+}
+
+// checkBudget 检查为 info 跟踪的交易再花费 extra（BTCW）手续费是否超出预算，调用者需持有 b.mu
+func (b *bumper) checkBudget(info *TxInfo, extra float64) error {
+	if max := b.config.MaxTxFee; max > 0 && info.Fee+extra > max {
+		return fmt.Errorf("transaction fee budget exhausted: fee would be %.8f, maxTxFee %.8f", info.Fee+extra, max)
+	}
+	walletSpent, totalSpent := b.spent(info.WalletName)
+	if max := b.config.MaxWalletFee; max > 0 && walletSpent+extra > max {
+		return fmt.Errorf("wallet fee budget exhausted: %.8f spent in %v, bump needs %.8f, maxWalletFee %.8f", walletSpent, b.budgetWindow(), extra, max)
+	}
+	if max := b.config.MaxTotalFee; max > 0 && totalSpent+extra > max {
+		return fmt.Errorf("total fee budget exhausted: %.8f spent in %v, bump needs %.8f, maxTotalFee %.8f", totalSpent, b.budgetWindow(), extra, max)
+	}
+	return nil
+}
+
+// reserveBudget 检查预算并预留 extra，避免并发处理的钱包同时用掉同一份预算。
+// 提高费率后须调用 settle
+func (b *bumper) reserveBudget(info *TxInfo, extra float64) (*feeSpend, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkBudget(info, extra); err != nil {
+		return nil, err
+	}
+	spend := &feeSpend{Time: b.now(), Wallet: info.WalletName, Amount: extra}
+	b.spends = append(b.spends, spend)
+	return spend, nil
+}
+
+// settle 用实际花费 extra 更新预留并计入 info，txid 为空表示提高费率失败，取消预留
+func (b *bumper) settle(info *TxInfo, spend *feeSpend, txid string, extra float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if txid == "" {
+		for i, s := range b.spends {
+			if s == spend {
+				b.spends = append(b.spends[:i], b.spends[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+	info.Fee += extra
+	spend.TxID, spend.Amount = txid, extra
+	b.summary.Fees += extra
+}
+
+// logChainSummary 记录一条替换链停止跟踪时的手续费汇总
+func logChainSummary(sugar *zap.SugaredLogger, txid string, info *TxInfo, reason string) {
+	sugar.Infof("Fee summary (%s), wallet: %s, txid: %s, replaces: %v, cpfp parents: %v, feerate history: %v, original fee: %.8f, final fee: %.8f, spent on bumps: %.8f",
+		reason, info.WalletName, txid, info.Replaces, info.Parents, info.FeerateHistory, info.OrigFee, info.Fee, info.Fee-info.OrigFee)
+}
+
+// logBudgetSummary 记录滚动窗口内各钱包额外花费的手续费
+func (b *bumper) logBudgetSummary() {
+	_, total := b.spent("")
+	byWallet := make(map[string]float64)
+	for _, s := range b.spends {
+		byWallet[s.Wallet] += s.Amount
+	}
+	wallets := make([]string, 0, len(byWallet))
+	for w := range byWallet {
+		wallets = append(wallets, w)
+	}
+	sort.Strings(wallets)
+	for _, w := range wallets {
+		b.sugar.Infof("Fees spent on bumps in last %v, wallet: %s, amount: %.8f", b.budgetWindow(), w, byWallet[w])
+	}
+	b.sugar.Infof("Fees spent on bumps in last %v, all wallets: %.8f, maxTotalFee: %.8f", b.budgetWindow(), total, b.config.MaxTotalFee)
+}
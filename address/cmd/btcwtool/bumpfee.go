@@ -0,0 +1,466 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"address/rpc"
+
+	"go.uber.org/zap"
+)
+
+// BumpFeeConfig bumpfee 子命令的配置
+type BumpFeeConfig struct {
+	Node string `yaml:"node"`
+	// Wallets 选择处理的钱包
+	Wallets              WalletFilter `yaml:"wallets"`
+	IsBump               bool         `yaml:"isBump"`
+	BlockCheckInterval   int          `yaml:"blockCheckInterval"`
+	BumpfeeBlockInterval int          `yaml:"bumpfeeBlockInterval"`
+	FeeBumpAmount        float64      `yaml:"feeBumpAmount"`
+	FeeCap               float64      `yaml:"feeCap"`
+	// StateFile 保存跟踪中交易的文件，重启后继续计算区块间隔，为空时不保存
+	StateFile string `yaml:"stateFile"`
+	// Method 提高费率的方式：rbf、cpfp 或 auto，默认 rbf
+	Method string `yaml:"method"`
+	// WalletMethods 按钱包指定提高费率的方式，覆盖 Method
+	WalletMethods map[string]string `yaml:"walletMethods"`
+	// MaxTxFee 单个交易（含替换和 CPFP 子交易）累计手续费上限（BTCW），0 为不限制
+	MaxTxFee float64 `yaml:"maxTxFee"`
+	// MaxWalletFee 每个钱包在预算窗口内提高费率额外花费的手续费上限（BTCW），0 为不限制
+	MaxWalletFee float64 `yaml:"maxWalletFee"`
+	// MaxTotalFee 所有钱包在预算窗口内提高费率额外花费的手续费上限（BTCW），0 为不限制
+	MaxTotalFee float64 `yaml:"maxTotalFee"`
+	// BudgetWindowHours 滚动预算窗口（小时），默认 24
+	BudgetWindowHours int `yaml:"budgetWindowHours"`
+	// Concurrency 同时处理的钱包数，默认 4
+	Concurrency int `yaml:"concurrency"`
+	// Strategy 费率提升策略：linear、multiplicative、estimatesmartfee 或 percentile，默认 linear
+	Strategy string `yaml:"strategy"`
+	// WalletStrategies 按钱包指定费率提升策略，覆盖 Strategy
+	WalletStrategies map[string]string `yaml:"walletStrategies"`
+	// FeeBumpFactor multiplicative 策略每次提高的倍数，默认 1.5
+	FeeBumpFactor float64 `yaml:"feeBumpFactor"`
+	// ConfTarget estimatesmartfee 策略的确认目标（区块数），默认 2
+	ConfTarget int `yaml:"confTarget"`
+	// EstimateMode estimatesmartfee 策略的估算模式：economical 或 conservative，留空使用节点默认值
+	EstimateMode string `yaml:"estimateMode"`
+	// Percentile percentile 策略使用的交易池费率百分位，默认 50
+	Percentile float64 `yaml:"percentile"`
+}
+
+// bumpfeeCommand 每隔 bumpfeeBlockInterval 个区块对未确认交易执行 bumpfee
+var bumpfeeCommand = &command{
+	name:  "bumpfee",
+	usage: "bump the fee of unconfirmed wallet transactions every few blocks",
+	flags: func(fs *flag.FlagSet, config *Config) {
+		c := &config.BumpFee
+		fs.BoolVar(&c.IsBump, "bump", c.IsBump, "actually call bumpfee")
+		fs.IntVar(&c.BlockCheckInterval, "block-check-interval", c.BlockCheckInterval, "seconds between block height checks")
+		fs.IntVar(&c.BumpfeeBlockInterval, "bumpfee-block-interval", c.BumpfeeBlockInterval, "blocks to wait before bumping")
+		fs.Float64Var(&c.FeeBumpAmount, "fee-bump-amount", c.FeeBumpAmount, "fee rate increase per bump in sat/vB")
+		fs.Float64Var(&c.FeeCap, "fee-cap", c.FeeCap, "maximum fee rate in sat/vB")
+		fs.StringVar(&c.StateFile, "state-file", c.StateFile, "file that keeps tracked transactions across restarts")
+		fs.StringVar(&c.Method, "method", c.Method, "how to raise fees: rbf, cpfp, or auto (cpfp when bumpfee fails)")
+		c.Wallets.register(fs)
+		fs.IntVar(&c.Concurrency, "concurrency", c.Concurrency, "number of wallets processed concurrently")
+		fs.StringVar(&c.Strategy, "strategy", c.Strategy, "fee escalation strategy: linear, multiplicative, estimatesmartfee, or percentile")
+		fs.Float64Var(&c.MaxTxFee, "max-tx-fee", c.MaxTxFee, "maximum total fee in BTCW of one transaction and its replacements, 0 for no limit")
+		fs.Float64Var(&c.MaxWalletFee, "max-wallet-fee", c.MaxWalletFee, "maximum BTCW spent on bumps per wallet in the budget window, 0 for no limit")
+		fs.Float64Var(&c.MaxTotalFee, "max-total-fee", c.MaxTotalFee, "maximum BTCW spent on bumps across wallets in the budget window, 0 for no limit")
+	},
+	run: runBumpFee,
+}
+
+// TxInfo 用于跟踪交易信息
+type TxInfo struct {
+	WalletName       string  `json:"wallet"`
+	FirstBlockHeight int     `json:"firstBlockHeight"`
+	CurrentFeerate   float64 `json:"currentFeerate"`
+	// FeerateHistory 依次记录首次发现时和每次 bumpfee 后的费率
+	FeerateHistory []float64 `json:"feerateHistory"`
+	// Replaces 是被当前交易依次替换掉的 txid，最早的在前
+	Replaces []string `json:"replaces,omitempty"`
+	// Parents 是当前交易以 CPFP 方式为其付费的父交易，最早的在前
+	Parents []string `json:"cpfpParents,omitempty"`
+	// OrigFee 是首次发现时交易的手续费（BTCW）
+	OrigFee float64 `json:"origFee"`
+	// Fee 是替换链当前的手续费，包括 CPFP 子交易的手续费（BTCW）
+	Fee float64 `json:"fee"`
+}
+
+// bumper 保存 bumpfee 主循环的状态
+type bumper struct {
+	config BumpFeeConfig
+	isBump bool
+	client *rpc.Client
+	sugar  *zap.SugaredLogger
+
+	wallets         []string
+	txInfos         map[string]*TxInfo
+	lastBlockHeight int64
+	// spends 是预算窗口内每次提高费率额外花费的手续费
+	spends []*feeSpend
+	now    func() time.Time
+	// mu 保护并发处理钱包时共用的 txInfos、spends 和 summary
+	mu      sync.Mutex
+	summary bumpSummary
+}
+
+// bumpSummary 统计本次运行的操作，退出时输出
+type bumpSummary struct {
+	Cycles  int
+	Bumps   int     // bumpfee 替换的交易数
+	CPFPs   int     // 创建的 CPFP 子交易数
+	Failed  int     // bumpfee 或 CPFP 失败次数
+	Refused int     // 因预算不足放弃的次数
+	Fees    float64 // 额外花费的手续费（BTCW）
+}
+
+// count 在 b.mu 保护下更新统计
+func (b *bumper) count(f func(s *bumpSummary)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	f(&b.summary)
+}
+
+// logSummary 输出本次运行的统计和仍在跟踪的交易
+func (b *bumper) logSummary() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := b.summary
+	b.sugar.Infof("bumpfee summary: %d cycles, %d bumped, %d CPFP, %d failed, %d refused by budget, %.8f BTCW spent on fees, %d transactions still tracked",
+		s.Cycles, s.Bumps, s.CPFPs, s.Failed, s.Refused, s.Fees, len(b.txInfos))
+}
+
+// walletTxs 是一个钱包在一次 cycle 中处理的跟踪交易，各钱包并发处理互不影响
+type walletTxs struct {
+	name    string
+	client  *rpc.Client
+	sugar   *zap.SugaredLogger
+	txInfos map[string]*TxInfo
+	height  int64 // 当前区块高度
+}
+
+// takeTracked 取出钱包 walletName 跟踪的交易
+func (b *bumper) takeTracked(walletName string) map[string]*TxInfo {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	txInfos := make(map[string]*TxInfo)
+	for txid, info := range b.txInfos {
+		if info.WalletName == walletName {
+			txInfos[txid] = info
+			delete(b.txInfos, txid)
+		}
+	}
+	return txInfos
+}
+
+// putTracked 放回 takeTracked 取出并处理过的交易
+func (b *bumper) putTracked(txInfos map[string]*TxInfo) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for txid, info := range txInfos {
+		b.txInfos[txid] = info
+	}
+}
+
+func newBumper(config BumpFeeConfig, isBump bool, client *rpc.Client, sugar *zap.SugaredLogger) *bumper {
+	return &bumper{
+		config:          config,
+		isBump:          isBump,
+		client:          client,
+		sugar:           sugar,
+		txInfos:         make(map[string]*TxInfo),
+		lastBlockHeight: -1, // 初始设置为 -1 以确保第一次检测到区块高度变化
+		now:             time.Now,
+	}
+}
+
+func runBumpFee(app *App) error {
+	config := app.Config.BumpFee
+	node, err := app.Node(config.Node, RoleWallet)
+	if err != nil {
+		return err
+	}
+	client := node.Client
+	app.Sugar.Infof("")
+	app.Sugar.Infof("Starting bumpfee, RPC server: %s", client.URL())
+
+	if !validMethod(config.Method) {
+		return fmt.Errorf("invalid bumpfee method %q", config.Method)
+	}
+	b := newBumper(config, config.IsBump && !app.Flags.DryRun, client, app.Sugar)
+
+	// 获取钱包列表
+	b.wallets, err = node.Wallets(app.Ctx, config.Wallets)
+	if err != nil {
+		return fmt.Errorf("error listing wallets: %w", err)
+	}
+
+	// 读取上次运行保存的状态，并与交易池核对
+	if err := b.load(); err != nil {
+		return err
+	}
+	if err := b.reconcile(app.Ctx); err != nil {
+		return err
+	}
+
+	// 收到新区块通知时立即运行，否则每隔一定时间间隔运行，收到退出信号后完成当前一轮再退出
+	notifier := node.Notifier(app.Shutdown, app.Sugar, TopicHashBlock)
+	for app.Shutdown.Err() == nil {
+		if err := b.cycle(app.Ctx); err != nil {
+			b.sugar.Error("Error getting current block count", zap.Error(err))
+		}
+
+		if n, ok := notifier.Wait(app.Shutdown, time.Duration(config.BlockCheckInterval)*time.Second); ok {
+			b.sugar.Infof("ZMQ block notification: %s", n.BlockHash())
+		}
+	}
+	err = b.save()
+	b.logSummary()
+	return err
+}
+
+// cycle 检查一次区块高度并处理所有钱包的未确认交易
+func (b *bumper) cycle(ctx context.Context) error {
+	// 获取当前区块高度
+	currentBlockCount, err := b.client.GetBlockCount(ctx)
+	if err != nil {
+		return err
+	}
+	b.summary.Cycles++
+	if currentBlockCount != b.lastBlockHeight {
+		b.sugar.Infof("New block detected: %d", currentBlockCount)
+		b.logBudgetSummary()
+	}
+
+	// 各钱包并发处理，每个钱包只操作自己跟踪的交易，处理完放回
+	forEachWallet(ctx, b.sugar, b.wallets, b.config.Concurrency, func(ctx context.Context, walletName string, sugar *zap.SugaredLogger) error {
+		w := &walletTxs{
+			name:    walletName,
+			client:  b.client.Wallet(walletName),
+			sugar:   sugar,
+			txInfos: b.takeTracked(walletName),
+			height:  currentBlockCount,
+		}
+		defer b.putTracked(w.txInfos)
+		b.processWallet(ctx, w)
+		return nil
+	})
+	b.lastBlockHeight = currentBlockCount
+	if err := b.save(); err != nil {
+		b.sugar.Error("Error saving state", zap.Error(err))
+	}
+	return nil
+}
+
+// processWallet 跟踪钱包中的未确认交易，并对等待超过 bumpfeeBlockInterval 的交易提高费率
+func (b *bumper) processWallet(ctx context.Context, w *walletTxs) {
+	walletName, walletClient, currentBlockCount := w.name, w.client, w.height
+
+	// 获取未确认的交易 minconf=0, maxconf=0，指定minimumAmount 排除0.00001的UTXO
+	unspent, err := walletClient.ListUnspent(ctx, 0, 0, nil, true, &rpc.ListUnspentOptions{MinimumAmount: 0.00002})
+	if err != nil {
+		w.sugar.Error("Error getting unconfirmed txids for wallet", zap.String("wallet", walletName), zap.Error(err))
+		return
+	}
+
+	// 检测到区块高度变化，打印本钱包每个未确认交易的区块高度差
+	if currentBlockCount != b.lastBlockHeight && b.lastBlockHeight != -1 {
+		for txid, info := range w.txInfos {
+			blockHeightDiff := currentBlockCount - int64(info.FirstBlockHeight)
+			w.sugar.Infof("wallet: %s, transaction txid: %s, unconfirmed for block interval: %d", info.WalletName, txid, blockHeightDiff)
+		}
+	}
+
+	// 不再未确认的交易（已确认、被替换或输出已被花费）停止跟踪
+	coins := make(map[string][]rpc.Unspent, len(unspent))
+	for _, u := range unspent {
+		coins[u.TxID] = append(coins[u.TxID], u)
+	}
+	for txid, info := range w.txInfos {
+		if len(coins[txid]) == 0 {
+			logChainSummary(w.sugar, txid, info, "no longer unconfirmed")
+			delete(w.txInfos, txid)
+		}
+	}
+
+	seen := make(map[string]bool, len(unspent))
+	for _, u := range unspent {
+		txid := u.TxID
+		// 同一交易的多个UTXO只处理一次
+		if seen[txid] {
+			continue
+		}
+		seen[txid] = true
+
+		info, exists := w.txInfos[txid]
+		if exists && int(currentBlockCount)-info.FirstBlockHeight < b.config.BumpfeeBlockInterval {
+			continue
+		}
+
+		// 使用 getmempoolentry 获取交易的虚拟大小和祖先、后代交易
+		entry, err := b.client.GetMempoolEntry(ctx, txid)
+		if err != nil {
+			w.sugar.Error("Error getting mempool entry", zap.String("wallet", walletName), zap.String("txid", txid), zap.Error(err))
+			continue
+		}
+		rates := mempoolFeerates(entry)
+
+		// 检查和更新费率
+		if !exists {
+			info = &TxInfo{
+				WalletName:       walletName,
+				FirstBlockHeight: int(currentBlockCount),
+				CurrentFeerate:   rates.Real,
+				FeerateHistory:   []float64{rates.Real},
+				OrigFee:          entry.Fees.Base,
+				Fee:              entry.Fees.Base,
+			}
+			w.txInfos[txid] = info
+			w.sugar.Infof("Found a new unconfirmed transaction, wallet: %s, txid: %s, feerate: %.1f, effective package feerate: %.1f", info.WalletName, txid, rates.Real, rates.Effective)
+			if b.config.BumpfeeBlockInterval > 0 {
+				continue
+			}
+		}
+		info.CurrentFeerate = rates.Real
+
+		w.sugar.Infof("txid: %s, feerate: %.1f, ancestor feerate: %.1f (%d txs, %d vB), descendant feerate: %.1f (%d txs, %d vB), effective package feerate: %.1f",
+			txid, rates.Real, rates.Ancestor, entry.AncestorCount, entry.AncestorSize, rates.Descendant, entry.DescendantCount, entry.DescendantSize, rates.Effective)
+		// 子交易已通过 CPFP 把整个交易包的费率提高到上限，不需要替换
+		if rates.Effective >= b.config.FeeCap {
+			w.sugar.Infof("No bumped, effective package feerate %.1f already reaches feeCap %.1f", rates.Effective, b.config.FeeCap)
+			continue
+		}
+		// 以交易自身费率和交易包有效费率中的较高者为基础提高
+		currentFeerate := math.Max(rates.Real, rates.Effective)
+		strategyName, strategy := b.strategy(walletName)
+		newFeerate, err := strategy.NextFeerate(ctx, currentFeerate)
+		if err != nil {
+			w.sugar.Error("Error computing new feerate", zap.String("txid", txid), zap.String("strategy", strategyName), zap.Error(err))
+			continue
+		}
+		if newFeerate > b.config.FeeCap {
+			newFeerate = b.config.FeeCap
+		}
+		newFeerateRounded := int(math.Round(newFeerate))
+		// bumpfee incrementalFee at least 1 sat/vB
+		if newFeerate-currentFeerate < 1 {
+			w.sugar.Infof("No bumped, %s strategy feerate %.1f is less than 1 sat/vB above %.1f", strategyName, newFeerate, currentFeerate)
+			continue
+		}
+		w.sugar.Infof("Bumpfee for txid: %s, newFeerate: %d, strategy: %s", txid, newFeerateRounded, strategyName)
+		if !b.isBump {
+			// 移除旧的txid
+			delete(w.txInfos, txid)
+			w.sugar.Infof("IsBump is false, No bumped, Old txid: %s", txid)
+			continue
+		}
+		method := b.method(walletName)
+		if method == MethodCPFP {
+			b.bumpChild(ctx, w, txid, info, coins[txid], entry, newFeerate)
+			continue
+		}
+		// 替换交易与原交易大小相同，按新费率估算额外手续费
+		spend, err := b.reserveBudget(info, float64(newFeerateRounded)*float64(entry.VSize)/1e8-entry.Fees.Base)
+		if err != nil {
+			b.count(func(s *bumpSummary) { s.Refused++ })
+			w.sugar.Infof("No bumped, txid: %s, %v", txid, err)
+			continue
+		}
+		bumpResult, err := walletClient.BumpFee(ctx, txid, &rpc.BumpFeeOptions{FeeRate: float64(newFeerateRounded)})
+		if err != nil {
+			b.settle(info, spend, "", 0)
+			b.count(func(s *bumpSummary) { s.Failed++ })
+			w.sugar.Error("Error bumping fee", zap.String("txid", txid), zap.Error(err))
+			if method == MethodAuto {
+				b.bumpChild(ctx, w, txid, info, coins[txid], entry, newFeerate)
+			}
+			continue
+		}
+		// 移除旧的txid，替换交易从当前区块开始继续跟踪
+		b.settle(info, spend, bumpResult.TxID, bumpResult.Fee-bumpResult.OrigFee)
+		b.count(func(s *bumpSummary) { s.Bumps++ })
+		delete(w.txInfos, txid)
+		w.txInfos[bumpResult.TxID] = &TxInfo{
+			WalletName:       walletName,
+			FirstBlockHeight: int(currentBlockCount),
+			CurrentFeerate:   float64(newFeerateRounded),
+			FeerateHistory:   append(info.FeerateHistory, float64(newFeerateRounded)),
+			Replaces:         append(info.Replaces, txid),
+			Parents:          info.Parents,
+			OrigFee:          info.OrigFee,
+			Fee:              info.Fee,
+		}
+		w.sugar.Infof("New txid: %s, newFeerate: %d, replacements: %d, fee: %.8f", bumpResult.TxID, newFeerateRounded, len(info.Replaces)+1, info.Fee)
+	}
+}
+
+// bumpChild 用 CPFP 子交易提高 txid 所在交易包的费率，成功后改为跟踪子交易
+func (b *bumper) bumpChild(ctx context.Context, w *walletTxs, txid string, info *TxInfo, coins []rpc.Unspent, entry *rpc.MempoolEntry, target float64) {
+	address, scriptSize, err := changeAddress(ctx, w.client)
+	if err != nil {
+		b.count(func(s *bumpSummary) { s.Failed++ })
+		w.sugar.Error("Error getting CPFP change address", zap.String("txid", txid), zap.Error(err))
+		return
+	}
+	childFee, childVSize := cpfpChildFee(coins, scriptSize, entry, target)
+	spend, err := b.reserveBudget(info, float64(childFee)/1e8)
+	if err != nil {
+		b.count(func(s *bumpSummary) { s.Refused++ })
+		w.sugar.Infof("No CPFP, txid: %s, %v", txid, err)
+		return
+	}
+	childTxid, err := b.cpfp(ctx, w.client, coins, address, childFee)
+	if err != nil {
+		b.settle(info, spend, "", 0)
+		b.count(func(s *bumpSummary) { s.Failed++ })
+		w.sugar.Error("Error creating CPFP transaction", zap.String("txid", txid), zap.Error(err))
+		return
+	}
+	childFeerate := float64(childFee) / float64(childVSize)
+	b.settle(info, spend, childTxid, float64(childFee)/1e8)
+	b.count(func(s *bumpSummary) { s.CPFPs++ })
+	delete(w.txInfos, txid)
+	w.txInfos[childTxid] = &TxInfo{
+		WalletName:       info.WalletName,
+		FirstBlockHeight: int(w.height),
+		CurrentFeerate:   childFeerate,
+		FeerateHistory:   append(info.FeerateHistory, target),
+		Replaces:         info.Replaces,
+		Parents:          append(info.Parents, txid),
+		OrigFee:          info.OrigFee,
+		Fee:              info.Fee,
+	}
+	w.sugar.Infof("CPFP txid: %s for parent txid: %s, package target feerate: %.1f, child feerate: %.1f", childTxid, txid, target, childFeerate)
+}
+
+// feerates 是根据 getmempoolentry 计算的费率（sat/vB）
+type feerates struct {
+	// Real 是交易自身的费率
+	Real float64
+	// Ancestor 是交易及其未确认祖先整体的费率
+	Ancestor float64
+	// Descendant 是交易及其后代整体的费率
+	Descendant float64
+	// Effective 是打包时实际生效的费率：自身与祖先整体费率中的较低者，后代可以通过 CPFP 提高。
+	// 同时有低费率祖先和高费率后代时是近似值
+	Effective float64
+}
+
+func mempoolFeerates(entry *rpc.MempoolEntry) feerates {
+	// 手续费先换算为整数聪，避免浮点误差
+	sat := func(btc float64) float64 { return math.Round(btc * 1e8) }
+	rates := feerates{
+		Real:       sat(entry.Fees.Base) / float64(entry.VSize),
+		Ancestor:   sat(entry.Fees.Ancestor) / float64(entry.AncestorSize),
+		Descendant: sat(entry.Fees.Descendant) / float64(entry.DescendantSize),
+	}
+	rates.Effective = math.Max(math.Min(rates.Real, rates.Ancestor), rates.Descendant)
+	return rates
+}
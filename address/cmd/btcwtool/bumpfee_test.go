@@ -0,0 +1,468 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"address/rpc"
+	"address/rpc/rpctest"
+
+	"go.uber.org/zap"
+)
+
+func TestBumpFee(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	s.CreateWallet("payer")
+	s.Fund("payer", 1)
+	s.Mine(1)
+	s.CreateWallet("payee")
+	client := s.Client()
+	sent, err := client.Wallet("payer").SendMany(ctx, map[string]float64{s.NewAddress("payee", ""): 0.1}, rpc.SendManyOptions{Minconf: 1, FeeRate: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := BumpFeeConfig{IsBump: true, BumpfeeBlockInterval: 1, FeeBumpAmount: 10, FeeCap: 25}
+	b := newBumper(config, true, client, zap.NewNop().Sugar())
+	b.wallets = []string{"payer"}
+
+	// step 产生 blocks 个空块后运行一次 cycle，返回交易池中唯一的交易
+	step := func(blocks int) rpctest.Tx {
+		t.Helper()
+		s.MineEmpty(blocks)
+		if err := b.cycle(ctx); err != nil {
+			t.Fatal(err)
+		}
+		mempool := s.Mempool()
+		if len(mempool) != 1 {
+			t.Fatalf("mempool %v, want one transaction", mempool)
+		}
+		tx, _ := s.Tx(mempool[0])
+		return tx
+	}
+
+	// 第一次发现交易，同一高度内不提高费率
+	if tx := step(0); tx.TxID != sent.TxID {
+		t.Fatalf("tx bumped before any new block")
+	}
+	if tx := step(0); tx.TxID != sent.TxID {
+		t.Fatalf("tx bumped without a new block")
+	}
+
+	replaced := step(1)
+	if replaced.Replaces != sent.TxID || replaced.FeeRate() != 20 {
+		t.Fatalf("after one block got %+v, want replacement at 20 sat/vB", replaced)
+	}
+
+	// 替换交易继续跟踪，下一个区块提高到上限
+	tx := step(1)
+	if tx.FeeRate() != 25 || tx.Replaces != replaced.TxID {
+		t.Fatalf("feerate %v, want capped at 25", tx.FeeRate())
+	}
+
+	// 已达到上限，不再替换
+	if last := step(1); last.TxID != tx.TxID {
+		t.Fatalf("tx bumped past fee cap: %+v", last)
+	}
+	if n := s.Calls("bumpfee"); n != 2 {
+		t.Fatalf("bumpfee called %d times, want 2", n)
+	}
+	info := b.txInfos[tx.TxID]
+	if info == nil || !reflect.DeepEqual(info.FeerateHistory, []float64{10, 20, 25}) || !reflect.DeepEqual(info.Replaces, []string{sent.TxID, replaced.TxID}) {
+		t.Fatalf("unexpected tracking info %+v", info)
+	}
+}
+
+func TestBumpFeeStateFile(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	s.CreateWallet("payer")
+	s.Fund("payer", 1)
+	s.Mine(1)
+	client := s.Client()
+	payer := client.Wallet("payer")
+	sent, err := payer.SendMany(ctx, map[string]float64{s.NewAddress("payer", ""): 0.1}, rpc.SendManyOptions{Minconf: 1, FeeRate: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := BumpFeeConfig{IsBump: true, BumpfeeBlockInterval: 2, FeeBumpAmount: 10, FeeCap: 100, StateFile: filepath.Join(t.TempDir(), "state.json")}
+	b := newBumper(config, true, client, zap.NewNop().Sugar())
+	b.wallets = []string{"payer"}
+	if err := b.cycle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	firstHeight := s.Height()
+
+	// 程序停止期间交易在节点上被替换，且又产生了区块
+	bumped, err := payer.BumpFee(ctx, sent.TxID, &rpc.BumpFeeOptions{FeeRate: 15})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.MineEmpty(1)
+
+	restarted := newBumper(config, true, client, zap.NewNop().Sugar())
+	restarted.wallets = []string{"payer"}
+	if err := restarted.load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.reconcile(ctx); err != nil {
+		t.Fatal(err)
+	}
+	info := restarted.txInfos[bumped.TxID]
+	if len(restarted.txInfos) != 1 || info == nil {
+		t.Fatalf("tracked %v, want only %s", restarted.txInfos, bumped.TxID)
+	}
+	if info.FirstBlockHeight != int(firstHeight) || info.CurrentFeerate != 15 || !reflect.DeepEqual(info.Replaces, []string{sent.TxID}) {
+		t.Fatalf("unexpected tracking info %+v", info)
+	}
+
+	// 首次发现后第二个区块时提高费率，不因重启重新计数
+	s.MineEmpty(1)
+	if err := restarted.cycle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	mempool := s.Mempool()
+	if tx, _ := s.Tx(mempool[0]); len(mempool) != 1 || tx.Replaces != bumped.TxID {
+		t.Fatalf("mempool %v, want replacement of %s", mempool, bumped.TxID)
+	}
+
+	// 交易确认后停止跟踪
+	s.Mine(1)
+	if err := restarted.cycle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(restarted.txInfos) != 0 {
+		t.Fatalf("still tracking %v after confirmation", restarted.txInfos)
+	}
+}
+
+func TestBumpFeeDisabled(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	s.CreateWallet("payer")
+	s.Fund("payer", 1)
+	s.Mine(1)
+	client := s.Client()
+	if _, err := client.Wallet("payer").SendMany(ctx, map[string]float64{s.NewAddress("payer", ""): 0.1}, rpc.SendManyOptions{Minconf: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	b := newBumper(BumpFeeConfig{BumpfeeBlockInterval: 1, FeeBumpAmount: 10, FeeCap: 100}, false, client, zap.NewNop().Sugar())
+	b.wallets = []string{"payer"}
+	for i := 0; i < 3; i++ {
+		s.MineEmpty(1)
+		if err := b.cycle(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := s.Calls("bumpfee"); n != 0 {
+		t.Fatalf("bumpfee called %d times with isBump false", n)
+	}
+}
+
+func TestMempoolFeerates(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry rpc.MempoolEntry
+		want  feerates
+	}{
+		{
+			name:  "no relatives",
+			entry: rpc.MempoolEntry{VSize: 200, AncestorSize: 200, DescendantSize: 200, Fees: rpc.MempoolFees{Base: 0.00002, Ancestor: 0.00002, Descendant: 0.00002}},
+			want:  feerates{Real: 10, Ancestor: 10, Descendant: 10, Effective: 10},
+		},
+		{
+			name:  "low fee parent",
+			entry: rpc.MempoolEntry{VSize: 200, AncestorSize: 400, DescendantSize: 200, Fees: rpc.MempoolFees{Base: 0.00004, Ancestor: 0.00005, Descendant: 0.00004}},
+			want:  feerates{Real: 20, Ancestor: 12.5, Descendant: 20, Effective: 20},
+		},
+		{
+			name:  "high fee parent",
+			entry: rpc.MempoolEntry{VSize: 200, AncestorSize: 400, DescendantSize: 200, Fees: rpc.MempoolFees{Base: 0.00002, Ancestor: 0.0001, Descendant: 0.00002}},
+			want:  feerates{Real: 10, Ancestor: 25, Descendant: 10, Effective: 10},
+		},
+		{
+			name:  "high fee child",
+			entry: rpc.MempoolEntry{VSize: 200, AncestorSize: 200, DescendantSize: 400, Fees: rpc.MempoolFees{Base: 0.00002, Ancestor: 0.00002, Descendant: 0.0001}},
+			want:  feerates{Real: 10, Ancestor: 10, Descendant: 25, Effective: 25},
+		},
+	}
+	for _, tt := range tests {
+		if got := mempoolFeerates(&tt.entry); got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBumpFeeSkipsCPFPPackage(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	s.CreateWallet("payer")
+	s.Fund("payer", 1)
+	s.Mine(1)
+	client := s.Client()
+	payer := client.Wallet("payer")
+	parent, err := payer.SendMany(ctx, map[string]float64{s.NewAddress("payer", "a"): 0.1}, rpc.SendManyOptions{Minconf: 1, FeeRate: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 子交易花费父交易的找零，费率足以带动父交易
+	if _, err := payer.SendMany(ctx, map[string]float64{s.NewAddress("payer", "b"): 0.1}, rpc.SendManyOptions{FeeRate: 100}); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := client.GetMempoolEntry(ctx, parent.TxID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.DescendantCount != 2 {
+		t.Fatalf("parent has %d descendants, want 2", entry.DescendantCount)
+	}
+
+	b := newBumper(BumpFeeConfig{IsBump: true, BumpfeeBlockInterval: 1, FeeBumpAmount: 10, FeeCap: 30}, true, client, zap.NewNop().Sugar())
+	b.wallets = []string{"payer"}
+	for i := 0; i < 3; i++ {
+		s.MineEmpty(1)
+		if err := b.cycle(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := s.Calls("bumpfee"); n != 0 {
+		t.Fatalf("bumpfee called %d times for a package already above feeCap", n)
+	}
+}
+
+func TestBumpFeeCPFP(t *testing.T) {
+	tests := []struct {
+		name   string
+		wallet string
+		config BumpFeeConfig
+	}{
+		// 收到的交易无法 bumpfee，auto 模式改用 CPFP
+		{name: "auto on received tx", wallet: "payee", config: BumpFeeConfig{Method: MethodAuto}},
+		// 按钱包指定 cpfp，不调用 bumpfee
+		{name: "wallet method", wallet: "payer", config: BumpFeeConfig{Method: MethodRBF, WalletMethods: map[string]string{"payer": MethodCPFP}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := rpctest.NewServer()
+			defer s.Close()
+			ctx := context.Background()
+			s.CreateWallet("payer")
+			s.CreateWallet("payee")
+			s.Fund("payer", 1)
+			s.Mine(1)
+			client := s.Client()
+			parent, err := client.Wallet("payer").SendMany(ctx, map[string]float64{s.NewAddress("payee", ""): 0.1}, rpc.SendManyOptions{Minconf: 1, FeeRate: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			config := tt.config
+			config.IsBump, config.BumpfeeBlockInterval, config.FeeBumpAmount, config.FeeCap = true, 1, 10, 100
+			b := newBumper(config, true, client, zap.NewNop().Sugar())
+			b.wallets = []string{tt.wallet}
+			if err := b.cycle(ctx); err != nil {
+				t.Fatal(err)
+			}
+			s.MineEmpty(1)
+			if err := b.cycle(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			entry, err := client.GetMempoolEntry(ctx, parent.TxID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entry.DescendantCount != 2 {
+				t.Fatalf("parent has %d descendants, want a CPFP child", entry.DescendantCount)
+			}
+			if rates := mempoolFeerates(entry); rates.Descendant < 12 {
+				t.Fatalf("package feerate %.2f, want at least 12", rates.Descendant)
+			}
+			if len(b.txInfos) != 1 {
+				t.Fatalf("tracking %d transactions, want only the child", len(b.txInfos))
+			}
+			for childTxid, info := range b.txInfos {
+				if !reflect.DeepEqual(info.Parents, []string{parent.TxID}) || info.FeerateHistory[1] != 12 {
+					t.Fatalf("unexpected tracking info %+v", info)
+				}
+				if child, _ := s.Tx(childTxid); child.Wallet != tt.wallet {
+					t.Fatalf("child sent from %q, want %q", child.Wallet, tt.wallet)
+				}
+			}
+			if tt.config.Method == MethodRBF && s.Calls("bumpfee") != 0 {
+				t.Fatalf("bumpfee called for a cpfp wallet")
+			}
+		})
+	}
+}
+
+func TestCPFPVSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		coin       rpc.Unspent
+		scriptSize int
+		want       int64
+	}{
+		{name: "p2wpkh", coin: rpc.Unspent{ScriptPubKey: "0014" + strings.Repeat("00", 20)}, scriptSize: p2wpkhScriptSize, want: 110},
+		{name: "p2sh-p2wpkh", coin: rpc.Unspent{Desc: "sh(wpkh([d34db33f/49h/0h/0h/0/1]03a34b99f22c790c4e36b2b3c2c35a36db06226e41c692fc82b8b56ac1c540c5bd))#8zl0zxma"}, scriptSize: 23, want: 134},
+		{name: "p2tr", coin: rpc.Unspent{Desc: "tr([d34db33f/86h/0h/0h/0/1]a34b99f22c790c4e36b2b3c2c35a36db06226e41c692fc82b8b56ac1c540c5bd)#q4g6xz4h"}, scriptSize: 34, want: 111},
+		// 无法识别的输入按 P2PKH 估算
+		{name: "p2pkh", coin: rpc.Unspent{ScriptPubKey: "76a914" + strings.Repeat("00", 20) + "88ac"}, scriptSize: 25, want: 193},
+	}
+	for _, tt := range tests {
+		if got := cpfpVSize([]rpc.Unspent{tt.coin}, tt.scriptSize); got != tt.want {
+			t.Errorf("%s: cpfpVSize = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBumpFeeBudget(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	s.CreateWallet("payer")
+	s.CreateWallet("payee")
+	s.Fund("payer", 1)
+	s.Mine(1)
+	client := s.Client()
+	sent, err := client.Wallet("payer").SendMany(ctx, map[string]float64{s.NewAddress("payee", ""): 0.1}, rpc.SendManyOptions{Minconf: 1, FeeRate: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, _ := s.Tx(sent.TxID)
+	// 每次提高 10 sat/vB 额外花费 10*vsize 聪，预算只够一次
+	bump := float64(10*tx.VSize) / 1e8
+
+	config := BumpFeeConfig{IsBump: true, BumpfeeBlockInterval: 1, FeeBumpAmount: 10, FeeCap: 1000, MaxWalletFee: 1.5 * bump, BudgetWindowHours: 1}
+	b := newBumper(config, true, client, zap.NewNop().Sugar())
+	b.wallets = []string{"payer"}
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	for i := 0; i < 3; i++ {
+		if err := b.cycle(ctx); err != nil {
+			t.Fatal(err)
+		}
+		s.MineEmpty(1)
+	}
+	if n := s.Calls("bumpfee"); n != 1 {
+		t.Fatalf("bumpfee called %d times, want 1 within the wallet budget", n)
+	}
+
+	// 窗口过去后预算恢复
+	now = now.Add(2 * time.Hour)
+	if err := b.cycle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Calls("bumpfee"); n != 2 {
+		t.Fatalf("bumpfee called %d times, want 2 after the budget window", n)
+	}
+
+	// 单个交易的预算按替换链累计手续费计算
+	var info *TxInfo
+	for _, v := range b.txInfos {
+		info = v
+	}
+	if info == nil || math.Abs(info.Fee-info.OrigFee-2*bump) > 1e-9 {
+		t.Fatalf("unexpected tracking info %+v", info)
+	}
+	b.config.MaxWalletFee = 0
+	b.config.MaxTxFee = info.Fee + bump/2
+	s.MineEmpty(1)
+	if err := b.cycle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Calls("bumpfee"); n != 2 {
+		t.Fatalf("bumpfee called %d times, want maxTxFee to refuse the bump", n)
+	}
+}
+
+func TestBumpFeeWalletStrategy(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	s.CreateWallet("payer")
+	s.CreateWallet("payee")
+	s.Fund("payer", 1)
+	s.Mine(1)
+	s.SetSmartFee(33)
+	client := s.Client()
+	sent, err := client.Wallet("payer").SendMany(ctx, map[string]float64{s.NewAddress("payee", ""): 0.1}, rpc.SendManyOptions{Minconf: 1, FeeRate: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := BumpFeeConfig{IsBump: true, BumpfeeBlockInterval: 1, FeeBumpAmount: 10, FeeCap: 100, WalletStrategies: map[string]string{"payer": StrategyEstimate}}
+	b := newBumper(config, true, client, zap.NewNop().Sugar())
+	b.wallets = []string{"payer"}
+	if err := b.cycle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s.MineEmpty(1)
+	if err := b.cycle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	mempool := s.Mempool()
+	if len(mempool) != 1 {
+		t.Fatalf("mempool %v, want one transaction", mempool)
+	}
+	if tx, _ := s.Tx(mempool[0]); tx.Replaces != sent.TxID || tx.FeeRate() != 33 {
+		t.Fatalf("got %+v, want replacement at the estimatesmartfee rate 33 sat/vB", tx)
+	}
+}
+
+func TestRunBumpFeeShutdown(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	s.CreateWallet("payer")
+	s.Fund("payer", 1)
+	s.Mine(1)
+	sent, err := s.Client().Wallet("payer").SendMany(context.Background(), map[string]float64{s.NewAddress("payer", ""): 0.1}, rpc.SendManyOptions{Minconf: 1, FeeRate: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := newTestApp(t, s)
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	app.Config.BumpFee = BumpFeeConfig{IsBump: true, BlockCheckInterval: 60, BumpfeeBlockInterval: 1, FeeBumpAmount: 10, FeeCap: 100, StateFile: stateFile}
+	shutdown, cancel := context.WithCancel(context.Background())
+	app.Shutdown = shutdown
+	// 第一轮之后收到退出信号
+	s.Handle("getblockcount", func(string, []json.RawMessage) (interface{}, error) {
+		cancel()
+		return s.Height(), nil
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- runBumpFee(app) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("bumpfee did not stop after shutdown")
+	}
+	if n := s.Calls("getblockcount"); n != 1 {
+		t.Fatalf("ran %d cycles, want 1", n)
+	}
+	restarted := newBumper(app.Config.BumpFee, true, s.Client(), zap.NewNop().Sugar())
+	if err := restarted.load(); err != nil {
+		t.Fatal(err)
+	}
+	if restarted.txInfos[sent.TxID] == nil {
+		t.Fatalf("state file does not track %s", sent.TxID)
+	}
+}
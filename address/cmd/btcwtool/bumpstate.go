@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// bumpState 是 bumpfee 状态文件的内容
+type bumpState struct {
+	LastBlockHeight int64              `json:"lastBlockHeight"`
+	Txs             map[string]*TxInfo `json:"txs"`
+	Spends          []*feeSpend        `json:"spends,omitempty"`
+}
+
+// load 读取状态文件，文件不存在时从空状态开始
+func (b *bumper) load() error {
+	if b.config.StateFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(b.config.StateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading state file: %w", err)
+	}
+	var state bumpState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("error parsing state file %s: %w", b.config.StateFile, err)
+	}
+	if state.Txs != nil {
+		b.txInfos = state.Txs
+	}
+	b.lastBlockHeight = state.LastBlockHeight
+	b.spends = state.Spends
+	b.sugar.Infof("Loaded %d tracked transactions from %s", len(b.txInfos), b.config.StateFile)
+	return nil
+}
+
+// save 把跟踪的交易写入状态文件
+func (b *bumper) save() error {
+	if b.config.StateFile == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(bumpState{LastBlockHeight: b.lastBlockHeight, Txs: b.txInfos, Spends: b.spends}, "", " ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(b.config.StateFile, data); err != nil {
+		return fmt.Errorf("error writing state file: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic 先写临时文件再改名，避免中途退出留下不完整的文件
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// reconcile 用节点交易池核对读取的状态：已不在交易池中的交易被删除，
+// 在上次运行保存状态之前已被替换的交易转到替换交易名下
+func (b *bumper) reconcile(ctx context.Context) error {
+	if len(b.txInfos) == 0 {
+		return nil
+	}
+	txids, err := b.client.GetRawMempool(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting mempool: %w", err)
+	}
+	mempool := make(map[string]bool, len(txids))
+	for _, txid := range txids {
+		mempool[txid] = true
+	}
+
+	for txid, info := range b.txInfos {
+		if mempool[txid] {
+			continue
+		}
+		delete(b.txInfos, txid)
+		if !contains(b.wallets, info.WalletName) {
+			b.sugar.Infof("Dropped tracked transaction %s of wallet %s, wallet not loaded or not selected", txid, info.WalletName)
+			continue
+		}
+
+		// 沿替换链找到仍在交易池中的交易
+		walletClient := b.client.Wallet(info.WalletName)
+		chain := []string{txid}
+		tx, err := walletClient.GetTransaction(ctx, txid)
+		for err == nil && tx.ReplacedByTxID != "" && !mempool[tx.ReplacedByTxID] {
+			chain = append(chain, tx.ReplacedByTxID)
+			tx, err = walletClient.GetTransaction(ctx, tx.ReplacedByTxID)
+		}
+		switch {
+		case err != nil:
+			b.sugar.Infof("Dropped tracked transaction %s of wallet %s: %v", txid, info.WalletName, err)
+		case tx.ReplacedByTxID != "":
+			info.Replaces = append(info.Replaces, chain...)
+			if entry, err := b.client.GetMempoolEntry(ctx, tx.ReplacedByTxID); err == nil {
+				info.CurrentFeerate = mempoolFeerates(entry).Real
+				info.FeerateHistory = append(info.FeerateHistory, info.CurrentFeerate)
+				info.Fee = entry.Fees.Base
+			}
+			b.txInfos[tx.ReplacedByTxID] = info
+			b.sugar.Infof("Tracked transaction %s was replaced by %s, feerate: %.1f", txid, tx.ReplacedByTxID, info.CurrentFeerate)
+		default:
+			logChainSummary(b.sugar, txid, info, fmt.Sprintf("left the mempool, confirmations: %d", tx.Confirmations))
+		}
+	}
+	b.sugar.Infof("Reconciled state with mempool, tracking %d transactions", len(b.txInfos))
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
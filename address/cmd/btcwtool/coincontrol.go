@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+
+	"address/rpc"
+)
+
+// CoinControlConfig 控制 sendmany 花费的 UTXO。设置任一项后不再由 sendmany 自由选择输入，
+// 而是只从已确认的 UTXO 中按金额从大到小选择，用 createrawtransaction 和 fundrawtransaction 构建交易
+type CoinControlConfig struct {
+	// MinUtxoAmount 只花费金额不小于此值的 UTXO，避免大额付款把粉尘输出一起花掉
+	MinUtxoAmount float64 `yaml:"minUtxoAmount"`
+	// ExcludeLabels 不花费这些标签的地址上的 UTXO，支持通配符
+	ExcludeLabels []string `yaml:"excludeLabels"`
+	// MaxInputs 每笔交易最多的输入数，为 0 时不限制
+	MaxInputs int `yaml:"maxInputs"`
+}
+
+// enabled 返回是否设置了任一选项
+func (c CoinControlConfig) enabled() bool {
+	return c.MinUtxoAmount > 0 || len(c.ExcludeLabels) > 0 || c.MaxInputs > 0
+}
+
+// validate 检查选项和标签通配符，选择输入需要按费率估算手续费，因此必须有确定的费率（hasFeerate）
+func (c CoinControlConfig) validate(hasFeerate bool) error {
+	if !c.enabled() {
+		return nil
+	}
+	var errs []error
+	if c.MinUtxoAmount < 0 || c.MaxInputs < 0 {
+		errs = append(errs, fmt.Errorf("minUtxoAmount and maxInputs must not be negative, got %v and %d", c.MinUtxoAmount, c.MaxInputs))
+	}
+	for _, pattern := range c.ExcludeLabels {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("bad label pattern %q", pattern))
+		}
+	}
+	if !hasFeerate {
+		errs = append(errs, errors.New("requires sendmany.feerate, or sendmany.fee.mode estimatesmartfee or percentile"))
+	}
+	return errors.Join(errs...)
+}
+
+// selectCoins 从 unspent 中选择支付 batch 和 feeRate（sat/vB）手续费的输入，金额大的优先，使输入数尽量少。
+// 只使用已确认、可花费且满足 c 的 UTXO，输入数达到 MaxInputs 仍不足时返回错误
+func (c CoinControlConfig) selectCoins(unspent []rpc.Unspent, batch *payoutBatch, feeRate float64) ([]rpc.Unspent, error) {
+	var candidates []rpc.Unspent
+	for _, u := range unspent {
+		if u.Confirmations < 1 || !u.Spendable || u.Amount < c.MinUtxoAmount || matchAny(c.ExcludeLabels, u.Label) {
+			continue
+		}
+		candidates = append(candidates, u)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Amount > candidates[j].Amount
+	})
+
+	// 按一个 P2WPKH 找零估算手续费，实际手续费由 fundrawtransaction 计算
+	outputBytes := 8 + compactSize(p2wpkhScriptSize) + p2wpkhScriptSize
+	for _, r := range batch.Recipients {
+		outputBytes += r.outputSize()
+	}
+	need := int64(math.Round(recipientsTotal(batch.Recipients) * 1e8))
+	var selected []rpc.Unspent
+	var amount, fee int64
+	for _, u := range candidates {
+		if c.MaxInputs > 0 && len(selected) >= c.MaxInputs {
+			break
+		}
+		selected = append(selected, u)
+		amount += int64(math.Round(u.Amount * 1e8))
+		fee = int64(math.Ceil(feeRate * float64(estimateVSize(len(selected), len(batch.Recipients)+1, outputBytes))))
+		if amount >= need+fee {
+			return selected, nil
+		}
+	}
+	return nil, fmt.Errorf("%d eligible UTXOs (%d selected, %.8f BTCW) cannot pay %.8f BTCW plus %.8f BTCW fee",
+		len(candidates), len(selected), float64(amount)/1e8, float64(need)/1e8, float64(fee)/1e8)
+}
+
+// inputs 读取钱包的 UTXO 并选择付款 batch 的输入
+func (c CoinControlConfig) inputs(ctx context.Context, walletClient *rpc.Client, batch *payoutBatch, feeRate float64) ([]rpc.TxInput, error) {
+	unspent, err := walletClient.ListUnspent(ctx, 1, 9999999, nil, false, &rpc.ListUnspentOptions{MinimumAmount: c.MinUtxoAmount})
+	if err != nil {
+		return nil, fmt.Errorf("error listing unspent: %w", err)
+	}
+	coins, err := c.selectCoins(unspent, batch, feeRate)
+	if err != nil {
+		return nil, err
+	}
+	inputs := make([]rpc.TxInput, len(coins))
+	for i, u := range coins {
+		inputs[i] = rpc.TxInput{TxID: u.TxID, Vout: u.Vout}
+	}
+	return inputs, nil
+}
+
+// sendCoinControl 用 c 选择的输入构建、签名并广播付款 batch 的交易。签名后先调用 prepare 记录 txid，
+// 广播结果未知时可以按 txid 到钱包中核对；广播前的错误包装为 notSentError
+func (c CoinControlConfig) sendCoinControl(ctx context.Context, walletClient *rpc.Client, batch *payoutBatch, fee sendFee, prepare func(txid string) error) (string, error) {
+	inputs, err := c.inputs(ctx, walletClient, batch, fee.Rate)
+	if err != nil {
+		return "", notSent(err)
+	}
+	raw, err := walletClient.CreateRawTransaction(ctx, inputs, batch.amounts(), true)
+	if err != nil {
+		return "", notSent(err)
+	}
+	// 不让钱包补充输入，只计算手续费和找零
+	addInputs := false
+	opts := fee.fundOptions()
+	opts.AddInputs = &addInputs
+	funded, err := walletClient.FundRawTransaction(ctx, raw, opts)
+	if err != nil {
+		return "", notSent(err)
+	}
+	signed, err := walletClient.SignRawTransactionWithWallet(ctx, funded.Hex)
+	if err != nil {
+		return "", notSent(err)
+	}
+	if !signed.Complete {
+		return "", notSent(fmt.Errorf("signing transaction incomplete: %v", signed.Errors))
+	}
+	decoded, err := walletClient.DecodeRawTransaction(ctx, signed.Hex)
+	if err != nil {
+		return "", notSent(err)
+	}
+	if err := prepare(decoded.TxID); err != nil {
+		return "", notSent(err)
+	}
+	// 保留节点默认的 maxfeerate，手续费异常高时节点拒绝广播
+	return walletClient.SendRawTransaction(ctx, signed.Hex, nil)
+}
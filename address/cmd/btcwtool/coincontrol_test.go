@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"address/rpc"
+	"address/rpc/rpctest"
+)
+
+func TestSelectCoins(t *testing.T) {
+	unspent := []rpc.Unspent{
+		{TxID: "dust", Amount: 0.00001, Confirmations: 10, Spendable: true},
+		{TxID: "small", Amount: 0.3, Confirmations: 10, Spendable: true},
+		{TxID: "reserve", Amount: 5, Label: "reserve-cold", Confirmations: 10, Spendable: true},
+		{TxID: "unconfirmed", Amount: 2, Spendable: true},
+		{TxID: "medium", Amount: 0.6, Confirmations: 1, Spendable: true},
+		{TxID: "watchonly", Amount: 3, Confirmations: 10},
+	}
+	batch := &payoutBatch{Recipients: []Recipient{{Address: "a", Amount: 0.5}, {Address: "b", Amount: 0.3}}}
+	c := CoinControlConfig{MinUtxoAmount: 0.0001, ExcludeLabels: []string{"reserve*"}}
+
+	coins, err := c.selectCoins(unspent, batch, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(coins) != 2 || coins[0].TxID != "medium" || coins[1].TxID != "small" {
+		t.Errorf("selected %+v, want medium and small", coins)
+	}
+
+	// 一个输入不够支付
+	c.MaxInputs = 1
+	if _, err := c.selectCoins(unspent, batch, 10); err == nil || !strings.Contains(err.Error(), "2 eligible UTXOs (1 selected") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestCoinControlValidate(t *testing.T) {
+	if err := (CoinControlConfig{}).validate(false); err != nil {
+		t.Errorf("disabled coin control: %v", err)
+	}
+	err := CoinControlConfig{MaxInputs: -1, ExcludeLabels: []string{"["}}.validate(false)
+	for _, want := range []string{"must not be negative", "bad label pattern", "requires sendmany.feerate"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want %q", err, want)
+		}
+	}
+}
+
+func TestSendCoinControlMaxFeeRate(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	s.CreateWallet("payer")
+	s.CreateWallet("payee")
+	s.Fund("payer", 1)
+	s.Mine(1)
+	batch := &payoutBatch{Recipients: []Recipient{{Address: s.NewAddress("payee", ""), Amount: 0.001}}}
+
+	// 超过节点默认 maxfeerate 的交易被拒绝，不会广播
+	_, err := CoinControlConfig{MinUtxoAmount: 0.0001}.sendCoinControl(context.Background(), s.Client().Wallet("payer"), batch, sendFee{Rate: 20000}, func(string) error { return nil })
+	if !rpc.IsCode(err, rpc.ErrCodeVerifyRejected) {
+		t.Fatalf("err = %v, want maxfeerate rejection", err)
+	}
+	if mempool := s.Mempool(); len(mempool) != 0 {
+		t.Fatalf("mempool has %d transactions, want none", len(mempool))
+	}
+}
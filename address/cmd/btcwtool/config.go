@@ -0,0 +1,240 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"address/rpc"
+
+	"gopkg.in/yaml.v2"
+)
+
+// 节点角色
+const (
+	RoleWallet = "wallet" // 加载钱包、发送交易的节点
+	RoleMiner  = "miner"  // 挖矿节点
+)
+
+// NodeProfile 是 nodes 中的一个命名节点
+type NodeProfile struct {
+	URL  string `yaml:"url"`
+	Role string `yaml:"role"`
+	// Wallets 允许操作的钱包，支持通配符，为空时不限制
+	Wallets []string `yaml:"wallets"`
+	// ZMQHashBlock、ZMQRawTx 和 ZMQHashTx 是节点 -zmqpubhashblock、-zmqpubrawtx 和 -zmqpubhashtx 的端点（tcp://host:port），
+	// 设置后收到通知立即处理，否则按间隔轮询。新交易通知设置 ZMQRawTx 或 ZMQHashTx 之一即可
+	ZMQHashBlock string `yaml:"zmqHashBlock"`
+	ZMQRawTx     string `yaml:"zmqRawTx"`
+	ZMQHashTx    string `yaml:"zmqHashTx"`
+	// Failover 是节点不可用时只读调用（区块高度、内存池、手续费估算等）改发的备用节点
+	Failover string `yaml:"failover"`
+	rpc.Auth `yaml:",inline"`
+}
+
+// AllowsWallet 返回钱包 name 是否在节点的钱包白名单中，白名单支持通配符
+func (p NodeProfile) AllowsWallet(name string) bool {
+	return len(p.Wallets) == 0 || matchAny(p.Wallets, name)
+}
+
+// RetryConfig 控制节点暂时不可用（连接失败、-28 预热中、503）时的重试，maxRetries 为 0 时不重试
+type RetryConfig struct {
+	MaxRetries       int `yaml:"maxRetries"`
+	InitialBackoffMs int `yaml:"initialBackoffMs"`
+	MaxBackoffMs     int `yaml:"maxBackoffMs"`
+}
+
+// Config 存储所有子命令的配置信息，每个子命令一个配置段
+type Config struct {
+	// Auth 是 nodes 中未设置认证信息的节点共用的认证配置
+	rpc.Auth `yaml:",inline"`
+	// Node 是配置段未指定 node 时使用的默认节点
+	Node  string                 `yaml:"node"`
+	Nodes map[string]NodeProfile `yaml:"nodes"`
+	Retry RetryConfig            `yaml:"retry"`
+
+	NewAddress   NewAddressConfig   `yaml:"newaddress"`
+	SendMany     SendManyConfig     `yaml:"sendmany"`
+	BumpFee      BumpFeeConfig      `yaml:"bumpfee"`
+	Uxtos        UxtosConfig        `yaml:"uxtos"`
+	NetworkChart NetworkChartConfig `yaml:"networkchart"`
+	Prioritise   PrioritiseConfig   `yaml:"prioritise"`
+	Generate     GenerateConfig     `yaml:"generate"`
+	WalletEdit   WalletEditConfig   `yaml:"walletedit"`
+}
+
+// LoadConfig 读取并校验配置文件，未知的配置项和缺少的字段都会报错
+func LoadConfig(path string) (*Config, error) {
+	configFile, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.UnmarshalStrict(configFile, &config); err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+	// sendmany 的费率上限默认与 bumpfee 相同
+	if config.SendMany.Fee.MaxFeerate == 0 {
+		config.SendMany.Fee.MaxFeerate = config.BumpFee.FeeCap
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// Profile 返回名为 name 的节点，未设置认证信息时使用顶层认证配置
+func (c *Config) Profile(name string) (NodeProfile, error) {
+	profile, ok := c.Nodes[name]
+	if !ok {
+		return NodeProfile{}, fmt.Errorf("unknown node %q", name)
+	}
+	if profile.Auth == (rpc.Auth{}) {
+		profile.Auth = c.Auth
+	}
+	return profile, nil
+}
+
+// Validate 检查节点定义是否完整，以及各配置段引用的节点是否存在且角色正确
+func (c *Config) Validate() error {
+	var errs []error
+
+	names := make([]string, 0, len(c.Nodes))
+	for name := range c.Nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		profile, _ := c.Profile(name)
+		if profile.URL == "" {
+			errs = append(errs, fmt.Errorf("nodes.%s: missing url", name))
+		}
+		if profile.Role != RoleWallet && profile.Role != RoleMiner {
+			errs = append(errs, fmt.Errorf("nodes.%s: role must be %q or %q, got %q", name, RoleWallet, RoleMiner, profile.Role))
+		}
+		for _, zmq := range []struct{ key, endpoint string }{{"zmqHashBlock", profile.ZMQHashBlock}, {"zmqRawTx", profile.ZMQRawTx}, {"zmqHashTx", profile.ZMQHashTx}} {
+			if zmq.endpoint != "" && !strings.HasPrefix(zmq.endpoint, "tcp://") {
+				errs = append(errs, fmt.Errorf("nodes.%s.%s: must be tcp://host:port, got %q", name, zmq.key, zmq.endpoint))
+			}
+		}
+		if err := (WalletFilter{Include: profile.Wallets}).validate(); err != nil {
+			errs = append(errs, fmt.Errorf("nodes.%s.wallets: %w", name, err))
+		}
+		if _, err := profile.Auth.Option(); err != nil {
+			errs = append(errs, fmt.Errorf("nodes.%s: %w", name, err))
+		}
+		if _, ok := c.Nodes[profile.Failover]; profile.Failover != "" && (!ok || profile.Failover == name) {
+			errs = append(errs, fmt.Errorf("nodes.%s.failover: must be another node, got %q", name, profile.Failover))
+		}
+	}
+	if c.Retry.MaxRetries < 0 || c.Retry.InitialBackoffMs < 0 || c.Retry.MaxBackoffMs < 0 {
+		errs = append(errs, fmt.Errorf("retry: values must not be negative, got %+v", c.Retry))
+	}
+
+	// checkRef 检查 field 引用的节点，role 为空时不限制角色
+	checkRef := func(field, name, role string) {
+		if name == "" {
+			return
+		}
+		profile, ok := c.Nodes[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown node %q", field, name))
+			return
+		}
+		if role != "" && profile.Role != role {
+			errs = append(errs, fmt.Errorf("%s: node %q has role %q, want %q", field, name, profile.Role, role))
+		}
+	}
+	checkRef("node", c.Node, "")
+	checkRef("newaddress.node", c.NewAddress.Node, RoleWallet)
+	checkRef("sendmany.node", c.SendMany.Node, RoleWallet)
+	checkRef("sendmany.psbt.signer", c.SendMany.PSBT.Signer, RoleWallet)
+	checkRef("bumpfee.node", c.BumpFee.Node, RoleWallet)
+	checkRef("uxtos.node", c.Uxtos.Node, RoleWallet)
+	checkRef("networkchart.node", c.NetworkChart.Node, "")
+	checkRef("prioritise.node", c.Prioritise.Node, RoleWallet)
+	for i, name := range c.Prioritise.Miners {
+		checkRef(fmt.Sprintf("prioritise.miners[%d]", i), name, RoleMiner)
+	}
+	checkRef("generate.node", c.Generate.Node, RoleMiner)
+
+	if !validMethod(c.BumpFee.Method) {
+		errs = append(errs, fmt.Errorf("bumpfee.method: must be %q, %q or %q, got %q", MethodRBF, MethodCPFP, MethodAuto, c.BumpFee.Method))
+	}
+	for wallet, method := range c.BumpFee.WalletMethods {
+		if !validMethod(method) {
+			errs = append(errs, fmt.Errorf("bumpfee.walletMethods.%s: must be %q, %q or %q, got %q", wallet, MethodRBF, MethodCPFP, MethodAuto, method))
+		}
+	}
+	if c.SendMany.MaxTxVsize < 0 || c.SendMany.EstimatedInputs < 0 {
+		errs = append(errs, fmt.Errorf("sendmany: maxTxVsize and estimatedInputs must not be negative, got %d and %d", c.SendMany.MaxTxVsize, c.SendMany.EstimatedInputs))
+	}
+	if err := c.SendMany.Fee.validate(); err != nil {
+		errs = append(errs, fmt.Errorf("sendmany.fee.%w", err))
+	}
+	if c.SendMany.Fee.Mode != FeeModeConfTarget && float64(c.SendMany.Feerate) > c.SendMany.Fee.maxFeerate() {
+		errs = append(errs, fmt.Errorf("sendmany.feerate: must not exceed sendmany.fee.maxFeerate %v sat/vB, got %d", c.SendMany.Fee.maxFeerate(), c.SendMany.Feerate))
+	}
+	// 选择输入时需要按费率估算手续费
+	hasFeerate := c.SendMany.Fee.estimated() || c.SendMany.Fee.Mode != FeeModeConfTarget && c.SendMany.Feerate > 0
+	if err := c.SendMany.CoinControl.validate(hasFeerate); err != nil {
+		errs = append(errs, fmt.Errorf("sendmany.coinControl: %w", err))
+	}
+	if l := c.SendMany.MempoolLimits; l.AncestorCount < 0 || l.AncestorSizeKvB < 0 || l.DescendantCount < 0 || l.DescendantSizeKvB < 0 {
+		errs = append(errs, fmt.Errorf("sendmany.mempoolLimits: values must not be negative, got %+v", l))
+	}
+	if c.SendMany.PSBT.enabled() && c.SendMany.JournalFile == "" {
+		errs = append(errs, errors.New("sendmany.psbt.dir: requires sendmany.journalFile to track PSBTs waiting for signatures"))
+	}
+	if c.SendMany.PSBT.Signer != "" && !c.SendMany.PSBT.enabled() {
+		errs = append(errs, errors.New("sendmany.psbt.signer: requires sendmany.psbt.dir"))
+	}
+	if !validFormat(c.SendMany.AddressFormat) {
+		errs = append(errs, fmt.Errorf("sendmany.addressFormat: must be %q, %q or %q, got %q", FormatJSON, FormatCSV, FormatText, c.SendMany.AddressFormat))
+	}
+	if !validStrategy(c.BumpFee.Strategy) {
+		errs = append(errs, fmt.Errorf("bumpfee.strategy: must be %q, %q, %q or %q, got %q", StrategyLinear, StrategyMultiplicative, StrategyEstimate, StrategyPercentile, c.BumpFee.Strategy))
+	}
+	for wallet, strategy := range c.BumpFee.WalletStrategies {
+		if !validStrategy(strategy) {
+			errs = append(errs, fmt.Errorf("bumpfee.walletStrategies.%s: must be %q, %q, %q or %q, got %q", wallet, StrategyLinear, StrategyMultiplicative, StrategyEstimate, StrategyPercentile, strategy))
+		}
+	}
+	if c.BumpFee.FeeBumpFactor != 0 && c.BumpFee.FeeBumpFactor <= 1 {
+		errs = append(errs, fmt.Errorf("bumpfee.feeBumpFactor: must be greater than 1, got %v", c.BumpFee.FeeBumpFactor))
+	}
+	if c.BumpFee.ConfTarget < 0 {
+		errs = append(errs, fmt.Errorf("bumpfee.confTarget: must not be negative, got %d", c.BumpFee.ConfTarget))
+	}
+	if !validEstimateMode(c.BumpFee.EstimateMode) {
+		errs = append(errs, fmt.Errorf("bumpfee.estimateMode: must be \"economical\" or \"conservative\", got %q", c.BumpFee.EstimateMode))
+	}
+	if c.BumpFee.Percentile < 0 || c.BumpFee.Percentile > 100 {
+		errs = append(errs, fmt.Errorf("bumpfee.percentile: must be between 0 and 100, got %v", c.BumpFee.Percentile))
+	}
+	for _, section := range []struct {
+		name   string
+		filter WalletFilter
+	}{{"bumpfee", c.BumpFee.Wallets}, {"sendmany", c.SendMany.Wallets}, {"prioritise", c.Prioritise.Wallets}, {"uxtos", c.Uxtos.Wallets}} {
+		if err := section.filter.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s.wallets: %w", section.name, err))
+		}
+	}
+
+	checkBudget := func(key string, v float64) {
+		if v < 0 {
+			errs = append(errs, fmt.Errorf("bumpfee.%s: must not be negative, got %v", key, v))
+		}
+	}
+	checkBudget("maxTxFee", c.BumpFee.MaxTxFee)
+	checkBudget("maxWalletFee", c.BumpFee.MaxWalletFee)
+	checkBudget("maxTotalFee", c.BumpFee.MaxTotalFee)
+	if c.BumpFee.BudgetWindowHours < 0 {
+		errs = append(errs, fmt.Errorf("bumpfee.budgetWindowHours: must not be negative, got %d", c.BumpFee.BudgetWindowHours))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigExample(t *testing.T) {
+	config, err := LoadConfig("config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile, err := config.Profile(config.NewAddress.Node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Username != "USER" || !profile.AllowsWallet("btcw17") || profile.AllowsWallet("btcw1") {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+	// 未设置 sendmany.fee.maxFeerate 时使用 bumpfee.feeCap
+	if config.SendMany.Fee.maxFeerate() != config.BumpFee.FeeCap {
+		t.Errorf("sendmany maxFeerate = %v, want bumpfee feeCap %v", config.SendMany.Fee.maxFeerate(), config.BumpFee.FeeCap)
+	}
+}
+
+func TestLoadConfigUnknownKey(t *testing.T) {
+	path := writeConfig(t, `
+username: u
+nodes:
+  main:
+    url: http://127.0.0.1:9330
+    role: wallet
+    wallet: ["w1"]
+`)
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "wallet") {
+		t.Fatalf("want unknown field error, got %v", err)
+	}
+}
+
+func TestLoadConfigValidate(t *testing.T) {
+	path := writeConfig(t, `
+username: u
+nodes:
+  main:
+    role: wallet
+    failover: main
+  miner1:
+    url: http://127.0.0.1:9331
+    role: miner
+    failover: miner2
+sendmany:
+  node: miner1
+  addressFormat: xlsx
+retry:
+  maxRetries: -1
+prioritise:
+  miners: [miner2]
+bumpfee:
+  maxWalletFee: -1
+  strategy: fastest
+`)
+	_, err := LoadConfig(path)
+	if err == nil {
+		t.Fatal("want validation error")
+	}
+	for _, want := range []string{
+		"nodes.main: missing url",
+		`nodes.main.failover: must be another node, got "main"`,
+		`nodes.miner1.failover: must be another node, got "miner2"`,
+		"retry: values must not be negative",
+		`sendmany.addressFormat: must be "json", "csv" or "text", got "xlsx"`,
+		`sendmany.node: node "miner1" has role "miner", want "wallet"`,
+		`prioritise.miners[0]: unknown node "miner2"`,
+		"bumpfee.maxWalletFee: must not be negative",
+		`bumpfee.strategy: must be "linear", "multiplicative", "estimatesmartfee" or "percentile", got "fastest"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
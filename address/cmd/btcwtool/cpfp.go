@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"address/rpc"
+)
+
+// 提高费率的方式
+const (
+	MethodRBF  = "rbf"  // 用 bumpfee 替换交易（默认）
+	MethodCPFP = "cpfp" // 用子交易为父交易付费
+	MethodAuto = "auto" // 先 bumpfee，失败时改用 CPFP
+)
+
+// CPFP 子交易按输入和找零地址的实际脚本类型估算大小，无法识别的输入按 P2PKH 估算
+const (
+	p2pkhInputWeight      = 148 * 4
+	p2shP2WPKHInputWeight = (41+23)*4 + 108 // scriptSig 中是 P2WPKH 赎回脚本
+	p2trInputWeight       = 41*4 + 66       // 密钥路径花费，见证中只有一个 Schnorr 签名
+	// cpfpDustLimit 子交易输出低于此值（聪）时放弃 CPFP
+	cpfpDustLimit = 546
+)
+
+func validMethod(method string) bool {
+	return method == "" || method == MethodRBF || method == MethodCPFP || method == MethodAuto
+}
+
+// method 返回钱包使用的提高费率方式
+func (b *bumper) method(walletName string) string {
+	if m, ok := b.config.WalletMethods[walletName]; ok && m != "" {
+		return m
+	}
+	if b.config.Method == "" {
+		return MethodRBF
+	}
+	return b.config.Method
+}
+
+// inputWeight 按描述符或 scriptPubKey 估算花费 u 的输入权重
+func inputWeight(u rpc.Unspent) int {
+	switch {
+	case strings.HasPrefix(u.Desc, "wpkh("), len(u.ScriptPubKey) == 44 && strings.HasPrefix(u.ScriptPubKey, "0014"):
+		return p2wpkhInputWeight
+	case strings.HasPrefix(u.Desc, "sh(wpkh("):
+		return p2shP2WPKHInputWeight
+	case strings.HasPrefix(u.Desc, "tr("):
+		return p2trInputWeight
+	}
+	return p2pkhInputWeight
+}
+
+// cpfpVSize 估算花费 coins、输出到 scriptSize 字节找零脚本的子交易虚拟大小
+func cpfpVSize(coins []rpc.Unspent, scriptSize int) int64 {
+	weight := txOverheadWeight + 4*(compactSize(len(coins))+compactSize(1)+8+compactSize(scriptSize)+scriptSize)
+	for _, u := range coins {
+		weight += inputWeight(u)
+	}
+	return int64((weight + 3) / 4)
+}
+
+// cpfpChildFee 返回使父交易及其未确认祖先整体的费率达到 target（sat/vB）所需的子交易手续费和估算大小，
+// scriptSize 是找零脚本的字节数
+func cpfpChildFee(coins []rpc.Unspent, scriptSize int, entry *rpc.MempoolEntry, target float64) (fee, vsize int64) {
+	vsize = cpfpVSize(coins, scriptSize)
+	ancestorFees := int64(math.Round(entry.Fees.Ancestor * 1e8))
+	fee = int64(math.Ceil(target*float64(entry.AncestorSize+vsize))) - ancestorFees
+	// 子交易自身至少满足 1 sat/vB 的最低转发费率
+	if fee < vsize {
+		fee = vsize
+	}
+	return fee, vsize
+}
+
+// changeAddress 按钱包默认的找零类型生成新地址，返回地址和脚本字节数
+func changeAddress(ctx context.Context, walletClient *rpc.Client) (string, int, error) {
+	address, err := walletClient.GetRawChangeAddress(ctx, "")
+	if err != nil {
+		return "", 0, err
+	}
+	result, err := walletClient.ValidateAddress(ctx, address)
+	if err != nil {
+		return "", 0, err
+	}
+	if result.ScriptPubKey == "" {
+		return address, defaultScriptSize, nil
+	}
+	return address, len(result.ScriptPubKey) / 2, nil
+}
+
+// cpfp 创建子交易，把钱包在父交易中的全部未花费输出扣除 fee（聪）后转到本钱包的找零地址 address，返回子交易的 txid
+func (b *bumper) cpfp(ctx context.Context, walletClient *rpc.Client, coins []rpc.Unspent, address string, fee int64) (string, error) {
+	if len(coins) == 0 {
+		return "", errors.New("no spendable output in the transaction")
+	}
+	inputs := make([]rpc.TxInput, len(coins))
+	var amount int64
+	for i, u := range coins {
+		inputs[i] = rpc.TxInput{TxID: u.TxID, Vout: u.Vout}
+		amount += int64(math.Round(u.Amount * 1e8))
+	}
+	if amount-fee < cpfpDustLimit {
+		return "", fmt.Errorf("outputs of %d sat cannot pay CPFP fee of %d sat", amount, fee)
+	}
+
+	raw, err := walletClient.CreateRawTransaction(ctx, inputs, map[string]float64{address: float64(amount-fee) / 1e8}, true)
+	if err != nil {
+		return "", err
+	}
+	signed, err := walletClient.SignRawTransactionWithWallet(ctx, raw)
+	if err != nil {
+		return "", err
+	}
+	if !signed.Complete {
+		return "", fmt.Errorf("signing CPFP transaction incomplete: %v", signed.Errors)
+	}
+	// 节点只按子交易自身的费率检查 maxfeerate，而子交易要为父交易付费，自身费率通常远高于节点的上限，
+	// 所以不做限制（0）；手续费已由 feeCap 和预算限制
+	noLimit := 0.0
+	return walletClient.SendRawTransaction(ctx, signed.Hex, &noLimit)
+}
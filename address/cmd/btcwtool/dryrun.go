@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"address/rpc"
+)
+
+// dryRunResult 是试运行中一笔交易的预览
+type dryRunResult struct {
+	TxID       string
+	VSize      int
+	Fee        float64 // BTCW
+	FeeRate    float64 // sat/vB
+	Inputs     int
+	InputTotal float64 // BTCW
+	Change     float64 // BTCW，没有找零时为 0
+	Allowed    bool
+	Reason     string // testmempoolaccept 拒绝或无法检查的原因
+}
+
+func (r *dryRunResult) String() string {
+	accept := "allowed"
+	if !r.Allowed {
+		accept = "rejected: " + r.Reason
+	}
+	return fmt.Sprintf("%d inputs (%.8f BTCW), %d vB, fee %.8f BTCW (%.2f sat/vB), change %.8f BTCW, testmempoolaccept %s",
+		r.Inputs, r.InputTotal, r.VSize, r.Fee, r.FeeRate, r.Change, accept)
+}
+
+// simulateSend 用 walletcreatefundedpsbt 创建付款 batch 的交易但不广播，设置了 coinControl 时只使用其选择的输入。
+// 用 analyzepsbt 和 decodepsbt 得到虚拟大小、输入和找零，签名后用 testmempoolaccept 检查能否进入交易池。
+// signerClient 为空时由发送钱包签名。试运行不锁定 UTXO，同一钱包的多笔预览可能使用相同的输入
+func simulateSend(ctx context.Context, client, walletClient, signerClient *rpc.Client, batch *payoutBatch, coinControl CoinControlConfig, fee sendFee) (*dryRunResult, error) {
+	funded, err := fundPSBT(ctx, walletClient, batch, coinControl, fee, false)
+	if err != nil {
+		return nil, err
+	}
+	analyzed, err := client.AnalyzePSBT(ctx, funded.PSBT)
+	if err != nil {
+		return nil, err
+	}
+	if analyzed.Error != "" {
+		return nil, errors.New(analyzed.Error)
+	}
+	decoded, err := client.DecodePSBT(ctx, funded.PSBT)
+	if err != nil {
+		return nil, err
+	}
+	result := &dryRunResult{
+		TxID:    decoded.Tx.TxID,
+		VSize:   analyzed.EstimatedVSize,
+		Fee:     funded.Fee,
+		FeeRate: analyzed.EstimatedFeeRate * 1e5,
+		Inputs:  len(decoded.Tx.Vin),
+	}
+	// 输入总额用输出加 walletcreatefundedpsbt 的手续费计算，非隔离见证输入在 decodepsbt 中没有 witness_utxo
+	total := int64(math.Round(funded.Fee * 1e8))
+	for _, out := range decoded.Tx.Vout {
+		total += int64(math.Round(out.Value * 1e8))
+	}
+	result.InputTotal = float64(total) / 1e8
+	if funded.ChangePos >= 0 && funded.ChangePos < len(decoded.Tx.Vout) {
+		result.Change = decoded.Tx.Vout[funded.ChangePos].Value
+	}
+
+	// 签名但不广播，watch-only 钱包无法签名时只报告大小和手续费
+	if signerClient == nil {
+		signerClient = walletClient
+	}
+	processed, err := signerClient.WalletProcessPSBT(ctx, funded.PSBT, true)
+	if err != nil {
+		result.Reason = fmt.Sprintf("cannot sign: %v", err)
+		return result, nil
+	}
+	if !processed.Complete {
+		result.Reason = "wallet cannot sign all inputs"
+		return result, nil
+	}
+	final, err := client.FinalizePSBT(ctx, processed.PSBT)
+	if err != nil {
+		return nil, err
+	}
+	if !final.Complete {
+		result.Reason = "PSBT cannot be finalized"
+		return result, nil
+	}
+	// 与广播时一样使用节点默认的 maxfeerate
+	accepted, err := client.TestMempoolAccept(ctx, []string{final.Hex}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(accepted) != 1 {
+		return nil, fmt.Errorf("testmempoolaccept returned %d results for 1 transaction", len(accepted))
+	}
+	result.Allowed, result.Reason = accepted[0].Allowed, accepted[0].RejectReason
+	return result, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+)
+
+// GenerateConfig generate 子命令的配置，一般指向挖矿节点
+type GenerateConfig struct {
+	Node string `yaml:"node"`
+}
+
+// generateCommand 向挖矿节点发送 generate RPC
+var generateCommand = &command{
+	name:  "generate",
+	usage: "send generate RPC to a mining node",
+	run:   runGenerate,
+}
+
+func runGenerate(app *App) error {
+	node, err := app.Node(app.Config.Generate.Node, RoleMiner)
+	if err != nil {
+		return err
+	}
+	client := node.Client
+	app.Sugar.Infof("Starting generate, mining RPC server: %s", client.URL())
+	if app.Flags.DryRun {
+		app.Sugar.Infof("Dry run, generate not sent")
+		return nil
+	}
+
+	var generateResp interface{}
+	if err := client.Call(app.Ctx, "generate", &generateResp); err != nil {
+		return fmt.Errorf("error generate: %w", err)
+	}
+	app.Sugar.Infof("%v", generateResp)
+	return nil
+}
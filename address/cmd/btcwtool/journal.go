@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"address/rpc"
+
+	"go.uber.org/zap"
+)
+
+// 付款日志中交易的状态
+const (
+	PayoutPending  = "pending" // 已开始发送，结果未知，重新运行时先到钱包中核对，无法核对时不会重发
+	PayoutSent     = "sent"
+	PayoutFailed   = "failed"   // 节点拒绝，重新运行时重发
+	PayoutUnsigned = "unsigned" // 已写出 PSBT，等待签名后广播
+)
+
+// journalReorgDepth 是核对时在记录的区块高度之前多扫描的区块数，覆盖发送后的链重组
+const journalReorgDepth = 6
+
+// payoutEntry 是付款日志中的一笔交易，第 Send 笔交易付款第 Send % 批数 批地址
+type payoutEntry struct {
+	Send       int         `json:"send"`
+	Batch      int         `json:"batch"`
+	Recipients []Recipient `json:"recipients"`
+	Amount     float64     `json:"amount"`
+	Wallet     string      `json:"wallet"`
+	TxID       string      `json:"txid,omitempty"`
+	Status     string      `json:"status"`
+	Error      string      `json:"error,omitempty"`
+	PSBT       string      `json:"psbt,omitempty"`   // 等待签名的 PSBT 文件
+	Height     int64       `json:"height,omitempty"` // 开始发送时的区块高度，核对时从这里开始扫描钱包交易
+	Time       time.Time   `json:"time"`
+}
+
+// notSentError 表示交易在广播前失败，确定没有发出
+type notSentError struct {
+	err error
+}
+
+func notSent(err error) error {
+	return &notSentError{err: err}
+}
+
+func (e *notSentError) Error() string { return e.err.Error() }
+
+func (e *notSentError) Unwrap() error { return e.err }
+
+// payoutJournal 记录一次付款中每笔交易的状态，每次变化都写入文件，重新运行时跳过已发送的交易。
+// Campaign 由分批后的地址和金额计算，地址文件变化后不能沿用旧的日志
+type payoutJournal struct {
+	Campaign string         `json:"campaign"`
+	Entries  []*payoutEntry `json:"entries"`
+
+	path     string // 为空时不写文件
+	readOnly bool   // 试运行时读取日志但不写入
+	batches  []payoutBatch
+	mu       sync.Mutex
+}
+
+// campaignID 返回分批结果的摘要
+func campaignID(batches []payoutBatch) string {
+	h := sha256.New()
+	for _, batch := range batches {
+		for _, r := range batch.Recipients {
+			fmt.Fprintf(h, "%d %s %.8f\n", batch.Index, r.Address, r.Amount)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// openJournal 读取 path 中的付款日志，文件不存在时新建，path 为空时只在内存中记录
+func openJournal(path string, batches []payoutBatch, readOnly bool) (*payoutJournal, error) {
+	j := &payoutJournal{Campaign: campaignID(batches), path: path, readOnly: readOnly, batches: batches}
+	if path == "" {
+		return j, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading journal: %w", err)
+	}
+	var saved payoutJournal
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("error parsing journal %s: %w", path, err)
+	}
+	if saved.Campaign != j.Campaign {
+		return nil, fmt.Errorf("journal %s was written for different recipients (campaign %s, now %s), move it away to start a new payout", path, saved.Campaign, j.Campaign)
+	}
+	j.Entries = saved.Entries
+	return j, nil
+}
+
+// save 写入日志文件，调用者持有 j.mu
+func (j *payoutJournal) save() error {
+	if j.path == "" || j.readOnly {
+		return nil
+	}
+	data, err := json.MarshalIndent(j, "", " ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(j.path, data); err != nil {
+		return fmt.Errorf("error writing journal: %w", err)
+	}
+	return nil
+}
+
+// comment 返回第 send 笔交易的 sendmany comment，用于重新运行时在钱包中找到这笔交易
+func (j *payoutJournal) comment(send int) string {
+	return "btcwtool sendmany " + j.Campaign + " #" + strconv.Itoa(send)
+}
+
+// entry 返回第 send 笔交易的记录，调用者持有 j.mu
+func (j *payoutJournal) entry(send int) *payoutEntry {
+	for _, e := range j.Entries {
+		if e.Send == send {
+			return e
+		}
+	}
+	return nil
+}
+
+// count 返回状态为 statuses 之一的交易数
+func (j *payoutJournal) count(statuses ...string) int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	n := 0
+	for _, e := range j.Entries {
+		if contains(statuses, e.Status) {
+			n++
+		}
+	}
+	return n
+}
+
+// reserve 为钱包 wallet 取下一笔未发送或发送失败的交易并标记为 pending，写入日志后才能发送。
+// height 是当前区块高度，为 0 时核对会扫描钱包的全部交易。前 maxSendCount 笔交易都已发送、正在发送或等待签名时返回 nil
+func (j *payoutJournal) reserve(maxSendCount int, wallet string, height int64) (*payoutEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for send := 0; send < maxSendCount; send++ {
+		e := j.entry(send)
+		if e != nil && e.Status != PayoutFailed {
+			continue
+		}
+		if e == nil {
+			batch := j.batches[send%len(j.batches)]
+			e = &payoutEntry{Send: send, Batch: batch.Index, Recipients: batch.Recipients, Amount: recipientsTotal(batch.Recipients)}
+			j.Entries = append(j.Entries, e)
+		}
+		previous := *e
+		e.Wallet, e.Status, e.Error, e.TxID, e.PSBT, e.Height, e.Time = wallet, PayoutPending, "", "", "", height, time.Now()
+		if err := j.save(); err != nil {
+			*e = previous
+			return nil, err
+		}
+		return e, nil
+	}
+	return nil, nil
+}
+
+// prepare 在广播自行构建的交易前记录 txid 并标记为 pending，结果未知时 reconcile 按 txid 核对
+func (j *payoutJournal) prepare(e *payoutEntry, txid string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e.Status, e.TxID = PayoutPending, txid
+	return j.save()
+}
+
+// markUnsigned 记录已写出等待签名的 PSBT 文件
+func (j *payoutJournal) markUnsigned(e *payoutEntry, file string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e.Status, e.PSBT, e.Time = PayoutUnsigned, file, time.Now()
+	return j.save()
+}
+
+// unsigned 返回等待签名的交易
+func (j *payoutJournal) unsigned() []*payoutEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var entries []*payoutEntry
+	for _, e := range j.Entries {
+		if e.Status == PayoutUnsigned {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// finish 记录发送结果。节点返回RPC错误或广播前失败时交易没有发出，标记为 failed 以便重发；
+// 网络错误等结果未知时保持 pending，由 reconcile 到钱包中核对
+func (j *payoutJournal) finish(e *payoutEntry, txid string, sendErr error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var rpcErr *rpc.Error
+	var notSentErr *notSentError
+	switch {
+	case sendErr == nil:
+		e.Status, e.TxID = PayoutSent, txid
+	case errors.As(sendErr, &rpcErr), errors.As(sendErr, &notSentErr):
+		e.Status, e.TxID, e.Error = PayoutFailed, "", sendErr.Error()
+	default:
+		e.Error = sendErr.Error()
+	}
+	e.Time = time.Now()
+	return j.save()
+}
+
+// reconcile 核对 pending 交易：知道 txid 时用 gettransaction 查询，否则用 listsinceblock 从开始发送时的区块高度起
+// 查找交易的 comment。找到时标记为 sent，确定没有发出时标记为 failed 以便重发；无法核对时保持 pending，
+// 不再重发，由操作员到钱包中确认后修改日志。只能在没有正在发送的交易时调用
+func (j *payoutJournal) reconcile(ctx context.Context, client *rpc.Client, sugar *zap.SugaredLogger) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	changed := false
+	for _, e := range j.Entries {
+		if e.Status != PayoutPending {
+			continue
+		}
+		txid, err := j.find(ctx, client, e)
+		switch {
+		case err != nil:
+			sugar.Warnf("Cannot check pending transaction %d (batch %d) in wallet %s: %v; it will not be sent again until resolved: "+
+				"check the wallet, then set its status in the journal to %q with its txid, or to %q to send it again", e.Send, e.Batch+1, e.Wallet, err, PayoutSent, PayoutFailed)
+			continue
+		case txid != "":
+			e.Status, e.TxID, e.Error = PayoutSent, txid, ""
+			sugar.Infof("Pending transaction %d (batch %d) was sent from wallet %s: txid: %s", e.Send, e.Batch+1, e.Wallet, txid)
+		default:
+			e.Status, e.TxID = PayoutFailed, ""
+			sugar.Infof("Pending transaction %d (batch %d) was not sent from wallet %s, will send again", e.Send, e.Batch+1, e.Wallet)
+		}
+		e.Time = time.Now()
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return j.save()
+}
+
+// find 在钱包中查找 pending 交易，返回发出的 txid；确定没有发出时返回空 txid，无法确定时返回错误
+func (j *payoutJournal) find(ctx context.Context, client *rpc.Client, e *payoutEntry) (string, error) {
+	walletClient := client.Wallet(e.Wallet)
+	if e.TxID != "" {
+		return findTx(ctx, walletClient, e.TxID)
+	}
+	// sendmany 在广播前把交易写入钱包，从开始发送前的区块起钱包中没有带 comment 的交易说明没有发出
+	blockHash := ""
+	if e.Height > journalReorgDepth {
+		var err error
+		if blockHash, err = client.GetBlockHash(ctx, e.Height-journalReorgDepth); err != nil {
+			return "", err
+		}
+	}
+	result, err := walletClient.ListSinceBlock(ctx, blockHash)
+	if err != nil {
+		return "", err
+	}
+	// bumpfee 的替换交易沿用 comment，取没有被替换的一笔
+	txid := ""
+	for _, tx := range result.Transactions {
+		match := tx.Category == "send" && tx.Comment == j.comment(e.Send) && !tx.Abandoned && tx.Confirmations >= 0
+		if match && (txid == "" || tx.ReplacedByTxID == "") {
+			txid = tx.TxID
+		}
+	}
+	return txid, nil
+}
+
+// findTx 用 gettransaction 核对广播前记录的 txid，被 bumpfee 替换时跟随替换交易。
+// 交易花费本钱包的输出，节点接受后钱包中一定有记录，因此钱包中没有、已放弃或与已确认交易冲突时没有发出
+func findTx(ctx context.Context, walletClient *rpc.Client, txid string) (string, error) {
+	replaced := false
+	for {
+		tx, err := walletClient.GetTransaction(ctx, txid)
+		if rpc.IsCode(err, rpc.ErrCodeInvalidAddress) && !replaced {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if tx.ReplacedByTxID != "" {
+			txid, replaced = tx.ReplacedByTxID, true
+			continue
+		}
+		if tx.Confirmations < 0 {
+			return "", nil
+		}
+		for _, d := range tx.Details {
+			if d.Abandoned {
+				return "", nil
+			}
+		}
+		return tx.TxID, nil
+	}
+}
@@ -0,0 +1,214 @@
+// btcwtool 是 BitcoinPoW 节点运维工具的统一入口，各功能以子命令形式提供
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// command 描述一个子命令
+type command struct {
+	name  string
+	usage string
+	// flags 把子命令自己的参数绑定到 config 中对应的字段，命令行参数覆盖 YAML 中的值
+	flags func(fs *flag.FlagSet, config *Config)
+	run   func(app *App) error
+}
+
+var commands = []*command{
+	newaddressCommand,
+	sendmanyCommand,
+	bumpfeeCommand,
+	uxtosCommand,
+	networkchartCommand,
+	prioritiseCommand,
+	generateCommand,
+	walletEditCommand,
+}
+
+// GlobalFlags 所有子命令共用的参数
+type GlobalFlags struct {
+	ConfigPath string
+	Node       string
+	LogFile    string
+	DryRun     bool
+}
+
+func (g *GlobalFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.ConfigPath, "config", "config.yaml", "path of the YAML config file")
+	fs.StringVar(&g.Node, "node", "", "name of the node in config, overrides the node of the subcommand")
+	fs.StringVar(&g.LogFile, "log-file", "", "log file path (default <subcommand>.log)")
+	fs.BoolVar(&g.DryRun, "dry-run", false, "do not send any transaction or modify wallets")
+}
+
+// App 是子命令运行时的上下文
+type App struct {
+	// Ctx 用于RPC调用，收到退出信号时不取消，正在进行的RPC可以完成
+	Ctx context.Context
+	// Shutdown 在收到 SIGINT/SIGTERM 时取消，长时间运行的命令据此结束循环
+	Shutdown context.Context
+	Config   *Config
+	Flags    GlobalFlags
+	Sugar    *zap.SugaredLogger
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: btcwtool [global flags] <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nGlobal flags:\n")
+	fs := flag.NewFlagSet("btcwtool", flag.ContinueOnError)
+	new(GlobalFlags).register(fs)
+	fs.SetOutput(os.Stderr)
+	fs.PrintDefaults()
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "btcwtool: %v\n", err)
+		}
+		os.Exit(2)
+	}
+}
+
+func run(args []string) error {
+	// 全局参数可以写在子命令之前，也可以写在之后
+	var globals GlobalFlags
+	globalFS := flag.NewFlagSet("btcwtool", flag.ContinueOnError)
+	globalFS.Usage = usage
+	globals.register(globalFS)
+	if err := globalFS.Parse(args); err != nil {
+		return err
+	}
+	if globalFS.NArg() == 0 {
+		usage()
+		return flag.ErrHelp
+	}
+
+	name := globalFS.Arg(0)
+	var cmd *command
+	for _, c := range commands {
+		if c.name == name {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		usage()
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	// 第一次解析只为拿到 --config，参数值暂存在临时配置中
+	cmdFS := newCommandFlagSet(cmd, &globals, &Config{})
+	if err := cmdFS.Parse(globalFS.Args()[1:]); err != nil {
+		return err
+	}
+	if cmdFS.NArg() > 0 {
+		return fmt.Errorf("%s: unexpected arguments %s", cmd.name, strings.Join(cmdFS.Args(), " "))
+	}
+
+	config, err := LoadConfig(globals.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	// 把命令行上显式给出的参数重新应用到读取后的配置上
+	var discard GlobalFlags
+	applyFS := newCommandFlagSet(cmd, &discard, config)
+	var setErr error
+	cmdFS.Visit(func(f *flag.Flag) {
+		if err := applyFS.Set(f.Name, f.Value.String()); err != nil && setErr == nil {
+			setErr = err
+		}
+	})
+	if setErr != nil {
+		return setErr
+	}
+
+	if globals.LogFile == "" {
+		globals.LogFile = cmd.name + ".log"
+	}
+	logger, closeLog, err := newLogger(globals.LogFile)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	shutdown, stop := handleSignals(logger.Sugar())
+	defer stop()
+	app := &App{
+		Ctx:      context.Background(),
+		Shutdown: shutdown,
+		Config:   config,
+		Flags:    globals,
+		Sugar:    logger.Sugar(),
+	}
+	if err := cmd.run(app); err != nil {
+		app.Sugar.Errorf("%s: %v", cmd.name, err)
+		return err
+	}
+	return nil
+}
+
+// handleSignals 返回在收到第一个 SIGINT/SIGTERM 时取消的 context，之后恢复信号的默认处理，
+// 再次收到信号时立即退出
+func handleSignals(sugar *zap.SugaredLogger) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			sugar.Infof("Received %v, finishing current work before exit, send again to exit immediately", sig)
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+		cancel()
+	}()
+	return ctx, cancel
+}
+
+func newCommandFlagSet(cmd *command, globals *GlobalFlags, config *Config) *flag.FlagSet {
+	fs := flag.NewFlagSet("btcwtool "+cmd.name, flag.ContinueOnError)
+	// 注册时会写入默认值，保留子命令之前已解析的全局参数
+	parsed := *globals
+	globals.register(fs)
+	*globals = parsed
+	if cmd.flags != nil {
+		cmd.flags(fs, config)
+	}
+	return fs
+}
+
+// newLogger 创建同时写入日志文件和标准输出的 zap logger
+func newLogger(logFilePath string) (*zap.Logger, func(), error) {
+	// 创建并打开日志文件
+	logFile, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot open log file: %w", err)
+	}
+
+	// 配置 zap
+	zapconfig := zap.NewProductionEncoderConfig()
+	zapconfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	core := zapcore.NewCore(
+		zapcore.NewJSONEncoder(zapconfig),
+		zapcore.NewMultiWriteSyncer(zapcore.AddSync(logFile), zapcore.AddSync(os.Stdout)),
+		zapcore.InfoLevel,
+	)
+	logger := zap.New(core)
+	return logger, func() {
+		logger.Sync() // Flushes buffer, if any
+		logFile.Close()
+	}, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"address/rpc/rpctest"
+
+	"go.uber.org/zap"
+)
+
+// newTestApp 返回连接模拟节点的 App，模拟节点在配置中名为 main
+func newTestApp(t *testing.T, s *rpctest.Server, wallets ...string) *App {
+	t.Helper()
+	return &App{
+		Ctx:      context.Background(),
+		Shutdown: context.Background(),
+		Config: &Config{
+			Auth:  s.Auth(),
+			Node:  "main",
+			Nodes: map[string]NodeProfile{"main": {URL: s.URL, Role: RoleWallet, Wallets: wallets}},
+		},
+		Sugar: zap.NewNop().Sugar(),
+	}
+}
+
+func TestHandleSignals(t *testing.T) {
+	shutdown, stop := handleSignals(zap.NewNop().Sugar())
+	defer stop()
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(os.Interrupt); err != nil {
+		t.Skipf("cannot send interrupt: %v", err)
+	}
+	select {
+	case <-shutdown.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown not cancelled by interrupt")
+	}
+}
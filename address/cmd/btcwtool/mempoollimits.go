@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"address/rpc"
+)
+
+// bitcoind 交易池链限制的默认值，见 -limitancestorcount、-limitancestorsize、-limitdescendantcount 和 -limitdescendantsize
+const (
+	defaultLimitAncestorCount     = 25
+	defaultLimitAncestorSizeKvB   = 101
+	defaultLimitDescendantCount   = 25
+	defaultLimitDescendantSizeKvB = 101
+)
+
+// MempoolLimits 是节点的交易池链限制，与节点的同名启动参数一致，为 0 时使用 bitcoind 的默认值
+type MempoolLimits struct {
+	AncestorCount     int `yaml:"limitAncestorCount"`
+	AncestorSizeKvB   int `yaml:"limitAncestorSize"`
+	DescendantCount   int `yaml:"limitDescendantCount"`
+	DescendantSizeKvB int `yaml:"limitDescendantSize"`
+}
+
+// withDefaults 返回填入默认值的限制
+func (l MempoolLimits) withDefaults() MempoolLimits {
+	if l.AncestorCount <= 0 {
+		l.AncestorCount = defaultLimitAncestorCount
+	}
+	if l.AncestorSizeKvB <= 0 {
+		l.AncestorSizeKvB = defaultLimitAncestorSizeKvB
+	}
+	if l.DescendantCount <= 0 {
+		l.DescendantCount = defaultLimitDescendantCount
+	}
+	if l.DescendantSizeKvB <= 0 {
+		l.DescendantSizeKvB = defaultLimitDescendantSizeKvB
+	}
+	return l
+}
+
+// mempoolChain 是新交易加入交易池后的链状态，数量和大小都包含新交易自身
+type mempoolChain struct {
+	AncestorCount   int
+	AncestorSize    int64 // vB
+	DescendantCount int   // 祖先中后代最多的交易的后代数
+	DescendantSize  int64 // 祖先中后代最大的交易的后代大小（vB）
+}
+
+// newMempoolChain 用 getmempoolentry 和 getmempoolancestors 计算花费 parents 的未确认输出、虚拟大小为 vsize 的新交易的链状态。
+// 多个父交易的共同祖先只计算一次，已确认的父交易没有祖先
+func newMempoolChain(ctx context.Context, client *rpc.Client, parents []string, vsize int) (mempoolChain, error) {
+	batch := client.NewBatch()
+	seen := make(map[string]bool)
+	for _, txid := range parents {
+		if seen[txid] {
+			continue
+		}
+		seen[txid] = true
+		batch.Add("getmempoolentry", &rpc.MempoolEntry{}, txid)
+		batch.Add("getmempoolancestors", &map[string]rpc.MempoolEntry{}, txid, true)
+	}
+	if err := batch.Send(ctx); err != nil {
+		return mempoolChain{}, err
+	}
+
+	ancestors := make(map[string]rpc.MempoolEntry)
+	calls := batch.Calls()
+	for i := 0; i < len(calls); i += 2 {
+		entry, parentAncestors := calls[i], calls[i+1]
+		if rpc.IsCode(entry.Err, rpc.ErrCodeInvalidAddress) {
+			// 父交易已经确认
+			continue
+		}
+		if entry.Err != nil {
+			return mempoolChain{}, fmt.Errorf("error getting mempool entry %s: %w", entry.Params[0], entry.Err)
+		}
+		if parentAncestors.Err != nil {
+			return mempoolChain{}, fmt.Errorf("error getting mempool ancestors of %s: %w", entry.Params[0], parentAncestors.Err)
+		}
+		ancestors[entry.Params[0].(string)] = *entry.Result.(*rpc.MempoolEntry)
+		for txid, a := range *parentAncestors.Result.(*map[string]rpc.MempoolEntry) {
+			ancestors[txid] = a
+		}
+	}
+
+	chain := mempoolChain{AncestorCount: 1, AncestorSize: int64(vsize), DescendantCount: 1, DescendantSize: int64(vsize)}
+	for _, a := range ancestors {
+		chain.AncestorCount++
+		chain.AncestorSize += a.VSize
+		// 新交易成为每个祖先的后代
+		chain.DescendantCount = max(chain.DescendantCount, int(a.DescendantCount)+1)
+		chain.DescendantSize = max(chain.DescendantSize, a.DescendantSize+int64(vsize))
+	}
+	return chain, nil
+}
+
+// violation 返回超过的限制，没有超过时为空
+func (c mempoolChain) violation(l MempoolLimits) string {
+	l = l.withDefaults()
+	switch {
+	case c.AncestorCount > l.AncestorCount:
+		return fmt.Sprintf("%d ancestors over limitancestorcount %d", c.AncestorCount, l.AncestorCount)
+	case c.AncestorSize > int64(l.AncestorSizeKvB)*1000:
+		return fmt.Sprintf("ancestor size %d vB over limitancestorsize %d kvB", c.AncestorSize, l.AncestorSizeKvB)
+	case c.DescendantCount > l.DescendantCount:
+		return fmt.Sprintf("%d descendants over limitdescendantcount %d", c.DescendantCount, l.DescendantCount)
+	case c.DescendantSize > int64(l.DescendantSizeKvB)*1000:
+		return fmt.Sprintf("descendant size %d vB over limitdescendantsize %d kvB", c.DescendantSize, l.DescendantSizeKvB)
+	}
+	return ""
+}
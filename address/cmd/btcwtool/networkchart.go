@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+
+	"address/rpc"
+)
+
+// NetworkChartConfig networkchart 子命令的配置
+type NetworkChartConfig struct {
+	Node    string `yaml:"node"`
+	NBlocks int    `yaml:"nblocks"`
+}
+
+// networkchartCommand 获取 TIME,HASHRATE,DIFFICULT 并保存到 csv，用 plot/import_plot.m 画图
+var networkchartCommand = &command{
+	name:  "networkchart",
+	usage: "get time, hashrate and difficulty every nblocks and save to csv",
+	flags: func(fs *flag.FlagSet, config *Config) {
+		fs.IntVar(&config.NetworkChart.NBlocks, "nblocks", config.NetworkChart.NBlocks, "block interval between samples (default 120)")
+	},
+	run: runNetworkChart,
+}
+
+func parseBits(bits string) *big.Int {
+	bitsInt, _ := strconv.ParseUint(bits, 16, 32)
+	coefficient := bitsInt & 0x00ffffff
+	exponent := bitsInt >> 24
+	target := new(big.Int).SetUint64(coefficient)
+	target.Lsh(target, uint(8*(exponent-3)))
+	return target
+}
+
+func calculateDifficulty(target *big.Int) *big.Float {
+	maxTarget := new(big.Int).SetUint64(0xFFFF)
+	maxTarget.Lsh(maxTarget, 8*(0x1D-3))
+	targetFloat := new(big.Float).SetInt(target)
+	maxTargetFloat := new(big.Float).SetInt(maxTarget)
+	difficulty := new(big.Float).Quo(maxTargetFloat, targetFloat)
+	return difficulty
+}
+
+func runNetworkChart(app *App) error {
+	config := app.Config.NetworkChart
+	sugar := app.Sugar
+	ctx := app.Ctx
+
+	// Apply defaults if needed
+	nblocks := config.NBlocks
+	if nblocks <= 0 {
+		nblocks = 120
+	}
+
+	node, err := app.Node(config.Node, "")
+	if err != nil {
+		return err
+	}
+	client := node.Client
+
+	// Get current block count
+	currentBlockCount, err := client.GetBlockCount(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block count: %w", err)
+	}
+	totalBlocks := int(currentBlockCount)
+
+	// Prepare CSV file
+	timestamp := time.Now().Format("20060102_150405")
+	csvFilename := fmt.Sprintf("networkchart_%s_nblocks_%d.csv", timestamp, nblocks)
+	csvFile, err := os.Create(csvFilename)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer csvFile.Close()
+	csvWriter := csv.NewWriter(csvFile)
+	defer csvWriter.Flush()
+
+	// Write CSV header
+	csvWriter.Write([]string{"Time", "Height", "Hashrate", "CalculatedDifficulty"})
+
+	// Fetch data for every nblocks interval, batching rpc.DefaultBatchSize heights per request
+	var heights []int
+	for height := 0; height <= totalBlocks; height += nblocks {
+		heights = append(heights, height)
+	}
+	for start := 0; start < len(heights); start += rpc.DefaultBatchSize {
+		chunk := heights[start:min(start+rpc.DefaultBatchSize, len(heights))]
+		sugar.Infof("height: %v - %v", chunk[0], chunk[len(chunk)-1])
+
+		// Get block hashes
+		hashBatch := client.NewBatch()
+		blockHashes := make([]string, len(chunk))
+		for i, height := range chunk {
+			hashBatch.Add("getblockhash", &blockHashes[i], height)
+		}
+		if err := hashBatch.Send(ctx); err != nil {
+			sugar.Errorf("Failed to get block hashes for heights %d - %d: %v", chunk[0], chunk[len(chunk)-1], err)
+			continue
+		}
+
+		// Get block headers and network hashrates
+		dataBatch := client.NewBatch()
+		headers := make([]rpc.BlockHeader, len(chunk))
+		hashrates := make([]float64, len(chunk))
+		headerCalls := make([]*rpc.BatchCall, len(chunk))
+		hashrateCalls := make([]*rpc.BatchCall, len(chunk))
+		for i, height := range chunk {
+			if call := hashBatch.Calls()[i]; call.Err != nil {
+				sugar.Errorf("Failed to get block hash for height %d: %v", height, call.Err)
+				continue
+			}
+			headerCalls[i] = dataBatch.Add("getblockheader", &headers[i], blockHashes[i], true)
+			hashrateCalls[i] = dataBatch.Add("getnetworkhashps", &hashrates[i], nblocks, height)
+		}
+		if err := dataBatch.Send(ctx); err != nil {
+			sugar.Errorf("Failed to get block data for heights %d - %d: %v", chunk[0], chunk[len(chunk)-1], err)
+			continue
+		}
+
+		for i, height := range chunk {
+			if headerCalls[i] == nil {
+				continue
+			}
+			if err := headerCalls[i].Err; err != nil {
+				sugar.Errorf("Failed to get block header for height %d: %v", height, err)
+				continue
+			}
+			if err := hashrateCalls[i].Err; err != nil {
+				sugar.Errorf("Failed to get network hashrate for height %d: %v", height, err)
+				continue
+			}
+
+			utcTime := time.Unix(headers[i].Time, 0).UTC().Format("2006/01/02 15:04:05")
+
+			// bits = "1c2a1115"
+			target := parseBits(headers[i].Bits)
+			calculatedDifficulty := calculateDifficulty(target)
+			difficulty, _ := calculatedDifficulty.Float64()
+
+			// Write to CSV
+			csvWriter.Write([]string{
+				utcTime,
+				strconv.Itoa(height),
+				fmt.Sprintf("%.3f", hashrates[i]),
+				fmt.Sprintf("%.3f", difficulty),
+			})
+		}
+	}
+
+	sugar.Infof("Data saved to %s", csvFilename)
+	return nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"address/rpc"
+)
+
+// NewAddressConfig newaddress 子命令的配置
+type NewAddressConfig struct {
+	Node            string `yaml:"node"`
+	IsCreateWallet  bool   `yaml:"isCreateWallet"`
+	NewWallet       string `yaml:"newWallet"`
+	IsCreateAddress bool   `yaml:"isCreateAddress"`
+	NewAddressCount int    `yaml:"newAddressCount"`
+	BatchSize       int    `yaml:"batchSize"`
+	Interval        int    `yaml:"interval"`
+	OutputFile      string `yaml:"outputFile"`
+}
+
+// newaddressCommand 用于创建wallet，生成address，并输出addresses列表到json
+var newaddressCommand = &command{
+	name:  "newaddress",
+	usage: "create wallet and addresses then save to JSON",
+	flags: func(fs *flag.FlagSet, config *Config) {
+		c := &config.NewAddress
+		fs.BoolVar(&c.IsCreateWallet, "create-wallet", c.IsCreateWallet, "create the wallet before generating addresses")
+		fs.StringVar(&c.NewWallet, "wallet", c.NewWallet, "wallet name")
+		fs.BoolVar(&c.IsCreateAddress, "create-address", c.IsCreateAddress, "generate new addresses")
+		fs.IntVar(&c.NewAddressCount, "count", c.NewAddressCount, "number of addresses to generate")
+		fs.IntVar(&c.BatchSize, "batch-size", c.BatchSize, "getnewaddress calls per batch request")
+		fs.StringVar(&c.OutputFile, "output", c.OutputFile, "output JSON file")
+	},
+	run: runNewAddress,
+}
+
+func runNewAddress(app *App) error {
+	format := "%-40s %v"
+	config := app.Config.NewAddress
+	sugar := app.Sugar
+	ctx := app.Ctx
+
+	node, err := app.Node(config.Node, RoleWallet)
+	if err != nil {
+		return err
+	}
+	client := node.Client
+	sugar.Infof("")
+	sugar.Infof(format, "Starting newaddress, RPC server:", client.URL())
+
+	// 调用 createwallet RPC
+	if config.IsCreateWallet && !app.Flags.DryRun {
+		createWalletResult, err := client.CreateWallet(ctx, config.NewWallet, rpc.CreateWalletOptions{LoadOnStartup: true})
+		if err != nil {
+			return fmt.Errorf("error creating wallet: %w", err)
+		}
+		sugar.Infof(format, "New BitcoinPow Wallets:", createWalletResult.Name)
+	}
+	sugar.Infof(format, "isCreatewallet:", config.IsCreateWallet)
+
+	// 调用 listwallets RPC
+	listWalletsResult, err := client.ListWallets(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing wallet: %w", err)
+	}
+	sugar.Infof(format, "Existing BitcoinPow Wallets:", listWalletsResult)
+
+	// 节点加载了多个钱包时必须指定钱包
+	walletClient := client
+	if config.NewWallet != "" {
+		walletClient, err = node.Wallet(config.NewWallet)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 调用 getnewaddress RPC
+	count := 0
+	if config.IsCreateAddress && !app.Flags.DryRun {
+		batchSize := config.BatchSize
+		if batchSize <= 0 {
+			batchSize = rpc.DefaultBatchSize
+		}
+		for created := 0; created < config.NewAddressCount; created += batchSize {
+			batch := walletClient.NewBatch()
+			for i := created; i < config.NewAddressCount && i < created+batchSize; i++ {
+				batch.Add("getnewaddress", nil, "", "legacy")
+			}
+			if err := batch.Send(ctx); err != nil {
+				sugar.Infof("Error getting new address: %v", err)
+				continue
+			}
+			for _, call := range batch.Calls() {
+				if call.Err != nil {
+					sugar.Infof("Error getting new address: %v", call.Err)
+				} else {
+					count++
+				}
+			}
+			time.Sleep(time.Duration(config.Interval) * time.Millisecond)
+		}
+	}
+	sugar.Infof(format, "isCreatAddress:", config.IsCreateAddress)
+	sugar.Infof(format, "Create new BitcoinPow addresses:", count)
+
+	// 调用 listreceivedbyaddress RPC
+	listReceivedResult, err := walletClient.ListReceivedByAddress(ctx, 1, true)
+	if err != nil {
+		return fmt.Errorf("error listing received by address: %w", err)
+	}
+	if app.Flags.DryRun {
+		sugar.Infof(format, "Dry run, addresses not saved:", len(listReceivedResult))
+		return nil
+	}
+
+	// 检查 OutputFile 文件是否已存在
+	if _, err := os.Stat(config.OutputFile); err == nil {
+		// 如果文件存在，报错并退出
+		return fmt.Errorf("output file %s already exists, exiting to prevent overwriting", config.OutputFile)
+	} else if !os.IsNotExist(err) {
+		// 如果检查文件存在时遇到其他错误，也报错并退出
+		return fmt.Errorf("error checking if output file exists: %w", err)
+	}
+
+	// 保存结果到文件
+	file, err := json.MarshalIndent(listReceivedResult, "", " ")
+	if err != nil {
+		return fmt.Errorf("error marshalling JSON: %w", err)
+	}
+	if err := os.WriteFile(config.OutputFile, file, 0666); err != nil {
+		return fmt.Errorf("error writing file: %w", err)
+	}
+	absolutePath, err := filepath.Abs(config.OutputFile)
+	if err != nil {
+		return fmt.Errorf("error getting absolute path: %w", err)
+	}
+	sugar.Infof(format, "Addresses list JSON file:", absolutePath)
+	return nil
+}
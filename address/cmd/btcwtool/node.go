@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"address/rpc"
+)
+
+// Node 是解析后的命名节点及其RPC客户端
+type Node struct {
+	Name    string
+	Profile NodeProfile
+	Client  *rpc.Client
+}
+
+// Wallets 返回节点加载的钱包中被 filter 选中的钱包，不在节点白名单中的钱包被跳过
+func (n *Node) Wallets(ctx context.Context, filter WalletFilter) ([]string, error) {
+	loaded, err := n.Client.ListWallets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wallets := make([]string, 0, len(loaded))
+	for _, name := range loaded {
+		if n.Profile.AllowsWallet(name) && filter.Match(name) {
+			wallets = append(wallets, name)
+		}
+	}
+	return wallets, nil
+}
+
+// Wallet 返回钱包 name 的RPC客户端，钱包不在白名单中时报错
+func (n *Node) Wallet(name string) (*rpc.Client, error) {
+	if !n.Profile.AllowsWallet(name) {
+		return nil, fmt.Errorf("wallet %q is not in the wallets of node %q", name, n.Name)
+	}
+	return n.Client.Wallet(name), nil
+}
+
+// Node 返回子命令所用的节点，--node 参数优先于配置段中的 node，都未设置时使用顶层 node。
+// role 为空时不检查节点角色
+func (app *App) Node(name, role string) (*Node, error) {
+	if app.Flags.Node != "" {
+		name = app.Flags.Node
+	}
+	if name == "" {
+		name = app.Config.Node
+	}
+	if name == "" {
+		return nil, errors.New("no node configured, set node in config or use --node")
+	}
+	return app.NamedNode(name, role)
+}
+
+// NamedNode 返回名为 name 的节点，不受 --node 参数影响
+func (app *App) NamedNode(name, role string) (*Node, error) {
+	profile, err := app.Config.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+	if role != "" && profile.Role != role {
+		return nil, fmt.Errorf("node %q has role %q, want %q", name, profile.Role, role)
+	}
+	var opts []rpc.Option
+	if profile.Failover != "" {
+		failover, err := app.Config.Profile(profile.Failover)
+		if err != nil {
+			return nil, fmt.Errorf("node %q failover: %w", name, err)
+		}
+		// 备用节点自身的 failover 不再级联
+		secondary, err := app.client(profile.Failover, failover)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, rpc.WithFailover(secondary))
+	}
+	client, err := app.client(name, profile, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Node{
+		Name:    name,
+		Profile: profile,
+		Client:  client,
+	}, nil
+}
+
+// client 创建节点 name 的RPC客户端，按顶层 retry 配置重试
+func (app *App) client(name string, profile NodeProfile, opts ...rpc.Option) (*rpc.Client, error) {
+	authOption, err := profile.Auth.Option()
+	if err != nil {
+		return nil, fmt.Errorf("node %q: %w", name, err)
+	}
+	retry := app.Config.Retry
+	policy := rpc.RetryPolicy{
+		MaxRetries: retry.MaxRetries,
+		Initial:    time.Duration(retry.InitialBackoffMs) * time.Millisecond,
+		Max:        time.Duration(retry.MaxBackoffMs) * time.Millisecond,
+		OnRetry: func(method string, attempt int, wait time.Duration, err error) {
+			app.Sugar.Warnf("Node %s: %s failed, retry %d/%d in %v: %v", name, method, attempt, retry.MaxRetries, wait, err)
+		},
+	}
+	opts = append([]rpc.Option{authOption, rpc.WithRetry(policy)}, opts...)
+	return rpc.NewClient(profile.URL, opts...), nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+
+	"address/zmq"
+
+	"go.uber.org/zap"
+)
+
+// 通知主题，与 bitcoind 的 -zmqpubhashblock、-zmqpubrawtx 和 -zmqpubhashtx 对应
+const (
+	TopicHashBlock = "hashblock"
+	TopicRawTx     = "rawtx"
+	TopicHashTx    = "hashtx"
+)
+
+// zmqReconnectInterval 是 ZMQ 连接失败或断开后重连的间隔
+var zmqReconnectInterval = 5 * time.Second
+
+// Notification 是节点的一条 ZMQ 通知
+type Notification struct {
+	Topic string
+	Body  []byte
+	Seq   uint32
+}
+
+// BlockHash 返回 hashblock 通知中的区块哈希
+func (n Notification) BlockHash() string {
+	return hex.EncodeToString(n.Body)
+}
+
+// Notifier 等待节点的通知。节点配置了 ZMQ 端点时订阅相应主题，
+// 未配置、连接失败或断开时 Wait 只按间隔返回，由调用者轮询
+type Notifier struct {
+	events chan Notification
+}
+
+// Notifier 为节点订阅 topics，ctx 结束时断开
+func (n *Node) Notifier(ctx context.Context, sugar *zap.SugaredLogger, topics ...string) *Notifier {
+	notifier := &Notifier{events: make(chan Notification, 1)}
+	// 同一端点的主题共用一个连接
+	endpoints := make(map[string][]string)
+	var order []string
+	for _, topic := range topics {
+		var endpoint string
+		switch topic {
+		case TopicHashBlock:
+			endpoint = n.Profile.ZMQHashBlock
+		case TopicRawTx:
+			endpoint = n.Profile.ZMQRawTx
+		case TopicHashTx:
+			endpoint = n.Profile.ZMQHashTx
+		}
+		if endpoint == "" {
+			sugar.Infof("No ZMQ %s endpoint for node %s, polling", topic, n.Name)
+			continue
+		}
+		if _, ok := endpoints[endpoint]; !ok {
+			order = append(order, endpoint)
+		}
+		endpoints[endpoint] = append(endpoints[endpoint], topic)
+	}
+	for _, endpoint := range order {
+		go notifier.subscribe(ctx, sugar, endpoint, endpoints[endpoint])
+	}
+	return notifier
+}
+
+// subscribe 保持到 endpoint 的订阅，断开后重连
+func (n *Notifier) subscribe(ctx context.Context, sugar *zap.SugaredLogger, endpoint string, topics []string) {
+	for {
+		sub, err := zmq.Dial(ctx, endpoint, topics...)
+		if err == nil {
+			sugar.Infof("Subscribed to ZMQ %v at %s", topics, endpoint)
+			stop := context.AfterFunc(ctx, func() { sub.Close() })
+			err = n.receive(sugar, sub)
+			stop()
+			sub.Close()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		sugar.Warnf("ZMQ %v at %s unavailable, polling until reconnected: %v", topics, endpoint, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(zmqReconnectInterval):
+		}
+	}
+}
+
+// receive 把收到的通知转发给 Wait，直到连接断开
+func (n *Notifier) receive(sugar *zap.SugaredLogger, sub *zmq.Subscriber) error {
+	next := make(map[string]uint32)
+	for {
+		frames, err := sub.Recv()
+		if err != nil {
+			return err
+		}
+		// bitcoind 的通知为主题、内容和小端序的 4 字节序号
+		if len(frames) != 3 || len(frames[2]) != 4 {
+			continue
+		}
+		notification := Notification{Topic: string(frames[0]), Body: frames[1], Seq: binary.LittleEndian.Uint32(frames[2])}
+		if want, ok := next[notification.Topic]; ok && notification.Seq != want {
+			sugar.Warnf("Missed %d ZMQ %s notifications", notification.Seq-want, notification.Topic)
+		}
+		next[notification.Topic] = notification.Seq + 1
+		// Wait 每次只需要知道有新通知，未取走的旧通知被新通知替换
+		select {
+		case n.events <- notification:
+		default:
+			select {
+			case <-n.events:
+			default:
+			}
+			select {
+			case n.events <- notification:
+			default:
+			}
+		}
+	}
+}
+
+// Debounce 在收到通知后再等待 window，期间到达的通知合并到已收到的这一条，ctx 结束时立即返回
+func (n *Notifier) Debounce(ctx context.Context, window time.Duration) {
+	timer := time.NewTimer(window)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return
+	}
+	select {
+	case <-n.events:
+	default:
+	}
+}
+
+// Wait 等待下一条通知，最多等待 interval。收到通知时返回 true，超时或 ctx 结束时返回 false
+func (n *Notifier) Wait(ctx context.Context, interval time.Duration) (Notification, bool) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case notification := <-n.events:
+		return notification, true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return Notification{}, false
+}
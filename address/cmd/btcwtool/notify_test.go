@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"address/rpc/rpctest"
+	"address/zmq"
+
+	"go.uber.org/zap"
+)
+
+func TestNotifierBlock(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	app := newTestApp(t, s)
+	profile := app.Config.Nodes["main"]
+	profile.ZMQHashBlock = s.ZMQ()
+	app.Config.Nodes["main"] = profile
+	node, err := app.Node("", RoleWallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifier := node.Notifier(ctx, zap.NewNop().Sugar(), TopicHashBlock)
+	for deadline := time.Now().Add(5 * time.Second); s.ZMQSubscribers(TopicHashBlock) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("notifier did not subscribe")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	hashes := s.MineEmpty(1)
+	n, ok := notifier.Wait(ctx, 5*time.Second)
+	if !ok || n.Topic != TopicHashBlock || n.BlockHash() != hashes[0] {
+		t.Fatalf("Wait = %+v, %v, want hashblock %s", n, ok, hashes[0])
+	}
+	// hashtx 未订阅，不会唤醒 Wait
+	s.CreateWallet("w")
+	s.Fund("w", 1)
+	if n, ok := notifier.Wait(ctx, 50*time.Millisecond); ok {
+		t.Fatalf("unexpected notification %+v", n)
+	}
+}
+
+func TestNotifierRawTx(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 只有 -zmqpubrawtx 的节点也能通知新交易
+	node := &Node{Name: "main", Profile: NodeProfile{ZMQRawTx: s.ZMQ()}}
+	notifier := node.Notifier(ctx, zap.NewNop().Sugar(), TopicHashBlock, TopicRawTx, TopicHashTx)
+	for deadline := time.Now().Add(5 * time.Second); s.ZMQSubscribers(TopicRawTx) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("notifier did not subscribe")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	s.CreateWallet("w")
+	s.Fund("w", 1)
+	if n, ok := notifier.Wait(ctx, 5*time.Second); !ok || n.Topic != TopicRawTx {
+		t.Fatalf("Wait = %+v, %v, want rawtx", n, ok)
+	}
+}
+
+func TestNotifierFallback(t *testing.T) {
+	defer func(d time.Duration) { zmqReconnectInterval = d }(zmqReconnectInterval)
+	zmqReconnectInterval = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 端点暂不可用时按间隔返回
+	p, err := zmq.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := p.Endpoint()
+	p.Close()
+	node := &Node{Name: "main", Profile: NodeProfile{ZMQHashBlock: endpoint}}
+	notifier := node.Notifier(ctx, zap.NewNop().Sugar(), TopicHashBlock)
+	start := time.Now()
+	if _, ok := notifier.Wait(ctx, 30*time.Millisecond); ok || time.Since(start) < 30*time.Millisecond {
+		t.Fatal("want Wait to fall back to the polling interval")
+	}
+
+	// 发布端恢复后重新订阅
+	p, err = zmq.Listen(endpoint[len("tcp://"):])
+	if err != nil {
+		t.Skipf("cannot listen on %s again: %v", endpoint, err)
+	}
+	defer p.Close()
+	for deadline := time.Now().Add(5 * time.Second); p.Subscribers(TopicHashBlock) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("notifier did not reconnect")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	p.Publish([]byte(TopicHashBlock), make([]byte, 32), []byte{0, 0, 0, 0})
+	if n, ok := notifier.Wait(ctx, 5*time.Second); !ok || n.BlockHash() != "0000000000000000000000000000000000000000000000000000000000000000" {
+		t.Fatalf("Wait = %+v, %v after reconnect", n, ok)
+	}
+}
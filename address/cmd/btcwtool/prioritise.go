@@ -0,0 +1,150 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"address/rpc"
+
+	"go.uber.org/zap"
+)
+
+// PrioritiseConfig prioritise 子命令的配置
+type PrioritiseConfig struct {
+	Node string `yaml:"node"`
+	// Wallets 选择检查未确认交易的钱包
+	Wallets       WalletFilter `yaml:"wallets"`
+	CheckInterval int          `yaml:"checkInterval"`
+	FeeDelta      float64      `yaml:"feeDelta"`
+	// Miners 发送 prioritisetransaction 的挖矿节点名称
+	Miners []string `yaml:"miners"`
+}
+
+// prioritiseDebounce 是收到通知后等待的时间，期间到达的通知合并为一次检查
+var prioritiseDebounce = 5 * time.Second
+
+// prioritiseCommand 在挖矿节点上对主节点钱包的未确认交易调用 prioritisetransaction
+var prioritiseCommand = &command{
+	name:  "prioritise",
+	usage: "prioritise unconfirmed wallet transactions on mining nodes",
+	flags: func(fs *flag.FlagSet, config *Config) {
+		c := &config.Prioritise
+		fs.IntVar(&c.CheckInterval, "check-interval", c.CheckInterval, "seconds between checks")
+		fs.Float64Var(&c.FeeDelta, "fee-delta", c.FeeDelta, "fee_delta passed to prioritisetransaction")
+		c.Wallets.register(fs)
+	},
+	run: runPrioritise,
+}
+
+func runPrioritise(app *App) error {
+	config := app.Config.Prioritise
+	sugar := app.Sugar
+	ctx := app.Ctx
+
+	node, err := app.Node(config.Node, RoleWallet)
+	if err != nil {
+		return err
+	}
+	client := node.Client
+	minerClients := make([]*rpc.Client, len(config.Miners))
+	for i, name := range config.Miners {
+		miner, err := app.NamedNode(name, RoleMiner)
+		if err != nil {
+			return err
+		}
+		minerClients[i] = miner.Client
+	}
+
+	sugar.Infof("Starting prioritisetransaction, transaction RPC server: %s", client.URL())
+	for _, minerClient := range minerClients {
+		sugar.Infof("Starting prioritisetransaction, mining RPC server: %s", minerClient.URL())
+	}
+
+	// 获取钱包列表
+	wallets, err := node.Wallets(ctx, config.Wallets)
+	if err != nil {
+		return fmt.Errorf("error listing wallets: %w", err)
+	}
+
+	// 新区块或新交易通知到达后检查，否则每隔 checkInterval 秒检查
+	notifier := node.Notifier(app.Shutdown, sugar, TopicHashBlock, TopicRawTx, TopicHashTx)
+	prioritisetransactionCircle := 0
+	prioritised, failed := 0, 0
+	// prioritisetransaction 的 fee_delta 会累加，done[i] 记录已在第 i 个挖矿节点上调整过的交易，每笔只调用一次。
+	// 交易确认后节点清除调整，不再出现在未确认交易中的 txid 从记录中删除
+	done := make([]map[string]bool, len(minerClients))
+	for i := range done {
+		done[i] = make(map[string]bool)
+	}
+	// 收到退出信号后完成当前RPC，不再处理其余钱包
+	for app.Shutdown.Err() == nil {
+		sugar.Infof("prioritisetransactionCircle: %d", prioritisetransactionCircle)
+		seen := make(map[string]bool)
+		complete := true
+		for _, walletName := range wallets {
+			if app.Shutdown.Err() != nil {
+				break
+			}
+			walletClient := client.Wallet(walletName)
+
+			sugar.Infof("Checking unconfirmed transactions for wallet: %s", walletClient.URL())
+
+			// Fetch unconfirmed transactions from the main node
+			unconfirmedTx, err := walletClient.ListUnspent(ctx, 0, 0, nil, true, &rpc.ListUnspentOptions{MinimumAmount: 0.00002})
+			if err != nil {
+				sugar.Errorf("Error fetching unconfirmed transactions: %v", err)
+				complete = false
+				continue
+			}
+
+			if len(unconfirmedTx) == 0 {
+				sugar.Info("No unconfirmed transactions found")
+				continue
+			}
+
+			for _, tx := range unconfirmedTx {
+				// 同一交易的多个输出只处理一次
+				if seen[tx.TxID] {
+					continue
+				}
+				seen[tx.TxID] = true
+				for i, minerClient := range minerClients {
+					if done[i][tx.TxID] {
+						continue
+					}
+					sugar.Infof("Processing mining node: %s", minerClient.URL())
+					if app.Flags.DryRun {
+						sugar.Infof("Dry run, not prioritising transaction %s", tx.TxID)
+						continue
+					}
+					if err := minerClient.PrioritiseTransaction(ctx, tx.TxID, config.FeeDelta); err != nil {
+						sugar.Error("Error prioritising transaction", zap.String("txid", tx.TxID), zap.String("node", minerClient.URL()), zap.Error(err))
+						failed++
+						continue
+					}
+					done[i][tx.TxID] = true
+					prioritised++
+					sugar.Infof("Successfully prioritised transaction %s on node %s, fee_delta %f", tx.TxID, minerClient.URL(), config.FeeDelta)
+				}
+			}
+		}
+		// 有钱包查询失败或提前退出时保留记录，避免对仍未确认的交易重复调整
+		if complete && app.Shutdown.Err() == nil {
+			for i := range done {
+				for txid := range done[i] {
+					if !seen[txid] {
+						delete(done[i], txid)
+					}
+				}
+			}
+		}
+		prioritisetransactionCircle += 1
+		if n, ok := notifier.Wait(app.Shutdown, time.Duration(config.CheckInterval)*time.Second); ok {
+			sugar.Infof("ZMQ %s notification", n.Topic)
+			notifier.Debounce(app.Shutdown, prioritiseDebounce)
+		}
+	}
+	sugar.Infof("prioritise summary: %d circles, %d prioritisetransaction calls succeeded, %d failed", prioritisetransactionCircle, prioritised, failed)
+	return nil
+}
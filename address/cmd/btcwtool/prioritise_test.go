@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"address/rpc"
+	"address/rpc/rpctest"
+)
+
+func TestRunPrioritiseOnce(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	s.CreateWallet("payer")
+	s.CreateWallet("payee")
+	s.Fund("payer", 1)
+	s.Mine(1)
+	// 付款和找零两个输出分别在两个钱包中
+	sent, err := s.Client().Wallet("payer").SendMany(context.Background(), map[string]float64{s.NewAddress("payee", ""): 0.1}, rpc.SendManyOptions{Minconf: 1, FeeRate: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := newTestApp(t, s)
+	app.Config.Nodes["miner"] = NodeProfile{URL: s.URL, Role: RoleMiner}
+	app.Config.Prioritise = PrioritiseConfig{FeeDelta: 1000, Miners: []string{"miner"}}
+	shutdown, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	app.Shutdown = shutdown
+
+	done := make(chan error, 1)
+	go func() { done <- runPrioritise(app) }()
+	// 每轮检查两个钱包，运行三轮后退出
+	for deadline := time.Now().Add(5 * time.Second); s.Calls("listunspent") < 6; {
+		if time.Now().After(deadline) {
+			t.Fatal("prioritise did not run three circles")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("prioritise did not stop after shutdown")
+	}
+
+	if n := s.Calls("prioritisetransaction"); n != 1 {
+		t.Fatalf("prioritisetransaction called %d times, want 1", n)
+	}
+	if delta := s.Prioritised(sent.TxID); delta != 1000 {
+		t.Fatalf("fee_delta %v, want 1000 applied once", delta)
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"address/rpc"
+
+	"go.uber.org/zap"
+)
+
+// PSBTConfig 让 sendmany 只创建 PSBT，由另一个钱包节点或离线钱包签名后再广播，发送钱包可以是 watch-only 钱包
+type PSBTConfig struct {
+	// Dir 是 PSBT 文件目录，设置后每笔交易用 walletcreatefundedpsbt 创建并写入 <campaign>-<n>.psbt，
+	// 签名后的 PSBT 放在同目录的 <campaign>-<n>.signed.psbt，之后每轮和重新运行时广播
+	Dir string `yaml:"dir"`
+	// Signer 是签名节点，设置后直接用其钱包的 walletprocesspsbt 签名，不需要签名文件
+	Signer string `yaml:"signer"`
+	// SignerWallet 是签名节点上的钱包，为空时与发送钱包同名
+	SignerWallet string `yaml:"signerWallet"`
+}
+
+// enabled 返回是否使用 PSBT 流程
+func (c PSBTConfig) enabled() bool {
+	return c.Dir != ""
+}
+
+// signedPSBTFile 返回 PSBT 文件对应的签名文件
+func signedPSBTFile(file string) string {
+	return strings.TrimSuffix(file, ".psbt") + ".signed.psbt"
+}
+
+// psbtSender 创建 PSBT 文件，并广播签名后的 PSBT
+type psbtSender struct {
+	config  PSBTConfig
+	client  *rpc.Client
+	signer  *Node // 未设置签名节点时为 nil
+	journal *payoutJournal
+	sugar   *zap.SugaredLogger
+}
+
+// create 用 walletcreatefundedpsbt 为 entry 创建付款 batch 的 PSBT 并写入文件，设置了 coinControl 时只使用其选择的输入。
+// 创建失败时标记为 failed；设置了签名节点时立即签名并广播
+func (p *psbtSender) create(ctx context.Context, walletClient *rpc.Client, entry *payoutEntry, batch *payoutBatch, coinControl CoinControlConfig, fee sendFee) error {
+	file, err := p.write(ctx, walletClient, entry, batch, coinControl, fee)
+	if err != nil {
+		return errors.Join(err, p.journal.finish(entry, "", notSent(err)))
+	}
+	if err := p.journal.markUnsigned(entry, file); err != nil {
+		return err
+	}
+	if p.signer == nil {
+		p.sugar.Infof("Sign it and save the signed PSBT as %s", signedPSBTFile(file))
+		return nil
+	}
+	return p.broadcast(ctx, entry)
+}
+
+// write 创建 PSBT 并写入 Dir，返回文件名。PSBT 的输入在钱包中锁定，等待签名期间之后的交易不会花费相同的输入
+func (p *psbtSender) write(ctx context.Context, walletClient *rpc.Client, entry *payoutEntry, batch *payoutBatch, coinControl CoinControlConfig, fee sendFee) (file string, err error) {
+	funded, err := fundPSBT(ctx, walletClient, batch, coinControl, fee, true)
+	if err != nil {
+		return "", err
+	}
+	// 没有写出 PSBT 时解锁其输入
+	defer func() {
+		if err == nil {
+			return
+		}
+		if decoded, decodeErr := p.client.DecodePSBT(ctx, funded.PSBT); decodeErr == nil {
+			p.unlock(ctx, walletClient, decoded.Tx.Vin)
+		}
+	}()
+	file = filepath.Join(p.config.Dir, p.journal.Campaign+"-"+strconv.Itoa(entry.Send)+".psbt")
+	// 重新创建失败的交易时删除旧的签名文件
+	if err := os.Remove(signedPSBTFile(file)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if err := writeFileAtomic(file, []byte(funded.PSBT+"\n")); err != nil {
+		return "", fmt.Errorf("error writing PSBT: %w", err)
+	}
+	p.sugar.Infof("Wrote PSBT for batch %d from wallet %s to %s, fee %.8f BTCW", batch.Index+1, entry.Wallet, file, funded.Fee)
+	return file, nil
+}
+
+// fundPSBT 用 walletcreatefundedpsbt 创建付款 batch 的 PSBT，设置了 coinControl 时只使用其选择的输入，lock 为 true 时锁定输入
+func fundPSBT(ctx context.Context, walletClient *rpc.Client, batch *payoutBatch, coinControl CoinControlConfig, fee sendFee, lock bool) (*rpc.WalletCreateFundedPSBTResult, error) {
+	opts := fee.fundOptions()
+	opts.LockUnspents = lock
+	var inputs []rpc.TxInput
+	if coinControl.enabled() {
+		var err error
+		if inputs, err = coinControl.inputs(ctx, walletClient, batch, fee.Rate); err != nil {
+			return nil, err
+		}
+		addInputs := false
+		opts.AddInputs = &addInputs
+	}
+	return walletClient.WalletCreateFundedPSBT(ctx, inputs, batch.amounts(), opts)
+}
+
+// outpoints 返回 vin 中的输出，去掉 lockunspent 不需要的 sequence
+func outpoints(vin []rpc.TxInput) []rpc.TxInput {
+	outputs := make([]rpc.TxInput, len(vin))
+	for i, in := range vin {
+		outputs[i] = rpc.TxInput{TxID: in.TxID, Vout: in.Vout}
+	}
+	return outputs
+}
+
+// unlock 解锁不会再广播的 PSBT 的输入 vin，失败时只记录警告
+func (p *psbtSender) unlock(ctx context.Context, walletClient *rpc.Client, vin []rpc.TxInput) {
+	if err := walletClient.LockUnspent(ctx, true, outpoints(vin)); err != nil {
+		p.sugar.Warnf("Error unlocking PSBT inputs: %v", err)
+	}
+}
+
+// lockUnsigned 重新锁定等待签名的 PSBT 的输入。walletcreatefundedpsbt 的锁定只保存在节点内存中，
+// 节点重启后需要重新锁定，否则之后的交易可能花费相同的输入
+func (p *psbtSender) lockUnsigned(ctx context.Context) {
+	locked := make(map[string]map[string]bool) // 钱包 -> 已锁定的 txid:vout
+	for _, entry := range p.journal.unsigned() {
+		if err := p.lock(ctx, entry, locked); err != nil {
+			p.sugar.Warnf("Error locking inputs of unsigned PSBT %s: %v", entry.PSBT, err)
+		}
+	}
+}
+
+// lock 锁定 entry 的 PSBT 中还没有锁定的输入
+func (p *psbtSender) lock(ctx context.Context, entry *payoutEntry, locked map[string]map[string]bool) error {
+	data, err := os.ReadFile(entry.PSBT)
+	if err != nil {
+		return err
+	}
+	decoded, err := p.client.DecodePSBT(ctx, strings.TrimSpace(string(data)))
+	if err != nil {
+		return err
+	}
+	walletClient := p.client.Wallet(entry.Wallet)
+	if locked[entry.Wallet] == nil {
+		outputs, err := walletClient.ListLockUnspent(ctx)
+		if err != nil {
+			return err
+		}
+		locked[entry.Wallet] = make(map[string]bool)
+		for _, o := range outputs {
+			locked[entry.Wallet][o.TxID+":"+strconv.Itoa(int(o.Vout))] = true
+		}
+	}
+	var missing []rpc.TxInput
+	for _, in := range outpoints(decoded.Tx.Vin) {
+		if key := in.TxID + ":" + strconv.Itoa(int(in.Vout)); !locked[entry.Wallet][key] {
+			missing = append(missing, in)
+			locked[entry.Wallet][key] = true
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if err := walletClient.LockUnspent(ctx, false, missing); err != nil {
+		return err
+	}
+	p.sugar.Infof("Locked %d inputs of unsigned PSBT %s in wallet %s", len(missing), entry.PSBT, entry.Wallet)
+	return nil
+}
+
+// signerClient 返回为 wallet 签名的钱包客户端：设置了签名节点时为其上的 SignerWallet（为空时同名）钱包，否则为 nil
+func (p *psbtSender) signerClient(wallet string) (*rpc.Client, string, error) {
+	if p == nil || p.signer == nil {
+		return nil, "", nil
+	}
+	walletName := p.config.SignerWallet
+	if walletName == "" {
+		walletName = wallet
+	}
+	client, err := p.signer.Wallet(walletName)
+	return client, walletName, err
+}
+
+// signed 返回 entry 签名后的 PSBT：设置了签名节点时用其钱包签名，否则读取签名文件，文件不存在时返回空
+func (p *psbtSender) signed(ctx context.Context, entry *payoutEntry) (string, error) {
+	if p.signer == nil {
+		data, err := os.ReadFile(signedPSBTFile(entry.PSBT))
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return strings.TrimSpace(string(data)), err
+	}
+	data, err := os.ReadFile(entry.PSBT)
+	if err != nil {
+		return "", err
+	}
+	signerClient, walletName, err := p.signerClient(entry.Wallet)
+	if err != nil {
+		return "", err
+	}
+	processed, err := signerClient.WalletProcessPSBT(ctx, strings.TrimSpace(string(data)), true)
+	if err != nil {
+		return "", fmt.Errorf("error signing with wallet %s on node %s: %w", walletName, p.signer.Name, err)
+	}
+	return processed.PSBT, nil
+}
+
+// broadcast 用 finalizepsbt 完成签名后的 PSBT 并用 sendrawtransaction 广播，还没有签名时保持 unsigned
+func (p *psbtSender) broadcast(ctx context.Context, entry *payoutEntry) error {
+	psbt, err := p.signed(ctx, entry)
+	if err != nil {
+		return fmt.Errorf("error reading signed PSBT for transaction %d: %w", entry.Send, err)
+	}
+	if psbt == "" {
+		p.sugar.Infof("Transaction %d (batch %d) waiting for %s", entry.Send, entry.Batch+1, signedPSBTFile(entry.PSBT))
+		return nil
+	}
+	final, err := p.client.FinalizePSBT(ctx, psbt)
+	if err != nil {
+		return fmt.Errorf("error finalizing PSBT for transaction %d: %w", entry.Send, err)
+	}
+	if !final.Complete {
+		p.sugar.Warnf("PSBT for transaction %d (batch %d) is not fully signed", entry.Send, entry.Batch+1)
+		return nil
+	}
+	decoded, err := p.client.DecodeRawTransaction(ctx, final.Hex)
+	if err != nil {
+		return fmt.Errorf("error decoding transaction %d: %w", entry.Send, err)
+	}
+	if err := p.journal.prepare(entry, decoded.TxID); err != nil {
+		return err
+	}
+	// 保留节点默认的 maxfeerate，签名后的 PSBT 手续费异常高时节点拒绝广播
+	txid, sendErr := p.client.Wallet(entry.Wallet).SendRawTransaction(ctx, final.Hex, nil)
+	if err := p.journal.finish(entry, txid, sendErr); err != nil {
+		return err
+	}
+	if sendErr != nil {
+		// 节点拒绝的交易标记为 failed，之后重新创建 PSBT，解锁这次的输入
+		if entry.Status == PayoutFailed {
+			p.unlock(ctx, p.client.Wallet(entry.Wallet), decoded.Vin)
+		}
+		return fmt.Errorf("error broadcasting transaction %d (batch %d): %w", entry.Send, entry.Batch+1, sendErr)
+	}
+	p.sugar.Infof("Send batch %d from wallet %s: txid: %s", entry.Batch+1, entry.Wallet, txid)
+	logRecipients(p.sugar, entry.Recipients, txid)
+	return nil
+}
+
+// broadcastAll 广播所有已签名的 PSBT
+func (p *psbtSender) broadcastAll(ctx context.Context) {
+	for _, entry := range p.journal.unsigned() {
+		if err := p.broadcast(ctx, entry); err != nil {
+			p.sugar.Warnf("%v", err)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"address/rpc"
+)
+
+// 收款地址文件格式
+const (
+	FormatJSON = "json" // newaddress 输出的 JSON 数组，每项可带 payout 和 label
+	FormatCSV  = "csv"  // address,amount,label 三列，第一行可以是表头
+	FormatText = "text" // 每行一个地址，后面可跟金额和标签，以空白分隔，# 开头为注释
+)
+
+// maxAmount 是单个地址金额的上限，与 BTCW 总量一致
+const maxAmount = 21000000
+
+// Recipient 是一个收款地址及其金额和标签
+type Recipient struct {
+	Address string  `json:"address"`
+	Amount  float64 `json:"amount"`
+	Label   string  `json:"label"`
+
+	scriptSize int // validateaddress 返回的 scriptPubKey 字节数，用于估算交易大小
+}
+
+// validFormat 返回 format 是否是支持的地址文件格式，空表示按扩展名判断
+func validFormat(format string) bool {
+	switch format {
+	case "", FormatJSON, FormatCSV, FormatText:
+		return true
+	}
+	return false
+}
+
+// fileFormat 按扩展名判断地址文件格式，.json 和 .csv 以外都按文本处理
+func fileFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return FormatJSON
+	case ".csv":
+		return FormatCSV
+	}
+	return FormatText
+}
+
+// ReadRecipients 读取收款地址文件，format 为空时按扩展名判断格式。
+// 没有金额的地址使用 defaultAmount，所有格式错误、无效金额和重复地址合并后一起返回
+func ReadRecipients(filename, format string, defaultAmount float64) ([]Recipient, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		format = fileFormat(filename)
+	}
+	var recipients []Recipient
+	switch format {
+	case FormatJSON:
+		recipients, err = parseJSONRecipients(data)
+	case FormatCSV:
+		recipients, err = parseCSVRecipients(data)
+	case FormatText:
+		recipients, err = parseTextRecipients(data)
+	default:
+		return nil, fmt.Errorf("unknown address file format %q", format)
+	}
+	// 无法解析的行被跳过，其余地址仍然检查，一次报告所有错误
+	if err := errors.Join(err, checkRecipients(recipients, defaultAmount)); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return recipients, nil
+}
+
+// jsonRecipient 是 JSON 地址文件的一项。newaddress 输出的 amount 是 listreceivedbyaddress 报告的已收金额，
+// 不是付款金额，因此忽略，付款金额写在 payout 中
+type jsonRecipient struct {
+	Address string  `json:"address"`
+	Payout  float64 `json:"payout"`
+	Label   string  `json:"label"`
+}
+
+// parseJSONRecipients 解析 JSON 数组，没有 payout 的地址使用默认金额
+func parseJSONRecipients(data []byte) ([]Recipient, error) {
+	var items []jsonRecipient
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	recipients := make([]Recipient, len(items))
+	for i, item := range items {
+		recipients[i] = Recipient{Address: item.Address, Amount: item.Payout, Label: item.Label}
+	}
+	return recipients, nil
+}
+
+// parseCSVRecipients 解析 CSV。第一行有一列为 address 时作为表头，按列名取 address、amount 和 label，
+// 否则依次为地址、金额和标签，后两列可省略
+func parseCSVRecipients(data []byte) ([]Recipient, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.Comment = '#'
+	r.TrimLeadingSpace = true
+	columns := map[string]int{"address": 0, "amount": 1, "label": 2}
+	var recipients []Recipient
+	var errs []error
+	for first := true; ; first = false {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+		if first && isHeader(record) {
+			columns = map[string]int{"amount": -1, "label": -1}
+			for i, name := range record {
+				columns[strings.ToLower(strings.TrimSpace(name))] = i
+			}
+			continue
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i >= 0 && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		recipient, err := parseRecipient(field("address"), field("amount"), field("label"))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", line, err))
+			continue
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, errors.Join(errs...)
+}
+
+// isHeader 返回 CSV 记录是否是包含 address 列的表头
+func isHeader(record []string) bool {
+	for _, name := range record {
+		if strings.EqualFold(strings.TrimSpace(name), "address") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTextRecipients 解析文本，每行为地址、可选的金额和可选的标签（标签可以包含空格）
+func parseTextRecipients(data []byte) ([]Recipient, error) {
+	var recipients []Recipient
+	var errs []error
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) > 3 {
+			fields = append(fields[:2], strings.Join(fields[2:], " "))
+		}
+		fields = append(fields, "", "")
+		recipient, err := parseRecipient(fields[0], fields[1], fields[2])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", line, err))
+			continue
+		}
+		recipients = append(recipients, recipient)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return recipients, errors.Join(errs...)
+}
+
+// parseRecipient 解析一行中的地址、金额和标签，金额为空时为 0，由 checkRecipients 使用默认金额
+func parseRecipient(address, amount, label string) (Recipient, error) {
+	recipient := Recipient{Address: address, Label: label}
+	if amount != "" {
+		v, err := strconv.ParseFloat(amount, 64)
+		if err != nil {
+			return recipient, fmt.Errorf("invalid amount %q", amount)
+		}
+		if v <= 0 {
+			return recipient, fmt.Errorf("amount must be positive, got %s", amount)
+		}
+		recipient.Amount = v
+	}
+	return recipient, nil
+}
+
+// checkRecipients 为没有金额的地址填入 defaultAmount，并检查地址非空、不重复，
+// 金额为正、不超过总量且最多 8 位小数
+func checkRecipients(recipients []Recipient, defaultAmount float64) error {
+	var errs []error
+	seen := make(map[string]int)
+	for i := range recipients {
+		r := &recipients[i]
+		if r.Amount == 0 {
+			r.Amount = defaultAmount
+		}
+		switch {
+		case r.Address == "":
+			errs = append(errs, fmt.Errorf("recipient %d: missing address", i+1))
+		case seen[r.Address] > 0:
+			errs = append(errs, fmt.Errorf("recipient %d: duplicate address %s, first at recipient %d", i+1, r.Address, seen[r.Address]))
+		default:
+			seen[r.Address] = i + 1
+		}
+		if err := checkAmount(r.Amount); err != nil {
+			errs = append(errs, fmt.Errorf("recipient %d (%s): %w", i+1, r.Address, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkAmount 检查金额为正、不超过总量且最多 8 位小数
+func checkAmount(amount float64) error {
+	switch {
+	case amount <= 0 || math.IsNaN(amount):
+		return fmt.Errorf("amount must be positive, got %v", amount)
+	case amount > maxAmount:
+		return fmt.Errorf("amount %v exceeds %d BTCW", amount, maxAmount)
+	case math.Abs(amount*1e8-math.Round(amount*1e8)) > 1e-6:
+		return fmt.Errorf("amount %v has more than 8 decimal places", amount)
+	}
+	return nil
+}
+
+// validateRecipients 用节点的 validateaddress 批量检查地址并记录脚本大小，返回所有无效地址的错误
+func validateRecipients(ctx context.Context, client *rpc.Client, recipients []Recipient) error {
+	var errs []error
+	for start := 0; start < len(recipients); start += rpc.DefaultBatchSize {
+		end := min(start+rpc.DefaultBatchSize, len(recipients))
+		batch := client.NewBatch()
+		for _, r := range recipients[start:end] {
+			batch.Add("validateaddress", &rpc.ValidateAddressResult{}, r.Address)
+		}
+		if err := batch.Send(ctx); err != nil {
+			return err
+		}
+		for i, call := range batch.Calls() {
+			if call.Err != nil {
+				errs = append(errs, call.Err)
+				continue
+			}
+			result := call.Result.(*rpc.ValidateAddressResult)
+			if !result.IsValid {
+				errs = append(errs, fmt.Errorf("recipient %d: invalid address %s: %s", start+i+1, recipients[start+i].Address, result.Error))
+				continue
+			}
+			recipients[start+i].scriptSize = len(result.ScriptPubKey) / 2
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// recipientsTotal 返回收款金额合计，按聪累加避免浮点误差
+func recipientsTotal(recipients []Recipient) float64 {
+	var sats int64
+	for _, r := range recipients {
+		sats += int64(math.Round(r.Amount * 1e8))
+	}
+	return float64(sats) / 1e8
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeRecipients(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadRecipients(t *testing.T) {
+	want := []Recipient{
+		{Address: "bpw1qaaaaaaaaaaaaaa", Amount: 0.5, Label: "alice"},
+		{Address: "bpw1qbbbbbbbbbbbbbb", Amount: 0.001},
+		{Address: "bpw1qcccccccccccccc", Amount: 2, Label: "bob and carol"},
+	}
+	for _, tt := range []struct {
+		name, content, format string
+	}{
+		// newaddress 输出中的 amount 是已收金额，不作为付款金额
+		{"addresses.json", `[{"address":"bpw1qaaaaaaaaaaaaaa","payout":0.5,"label":"alice"},
+			{"address":"bpw1qbbbbbbbbbbbbbb","amount":3,"confirmations":1,"txids":[]},
+			{"address":"bpw1qcccccccccccccc","amount":0,"payout":2,"label":"bob and carol"}]`, ""},
+		{"addresses.csv", "bpw1qaaaaaaaaaaaaaa,0.5,alice\nbpw1qbbbbbbbbbbbbbb\n# comment\nbpw1qcccccccccccccc, 2, bob and carol\n", ""},
+		{"header.csv", "label,address,amount\nalice,bpw1qaaaaaaaaaaaaaa,0.5\n,bpw1qbbbbbbbbbbbbbb,\nbob and carol,bpw1qcccccccccccccc,2\n", ""},
+		{"addresses.txt", "# payouts\nbpw1qaaaaaaaaaaaaaa 0.5 alice\n\nbpw1qbbbbbbbbbbbbbb\nbpw1qcccccccccccccc\t2  bob and carol\n", ""},
+		{"payouts", "bpw1qaaaaaaaaaaaaaa,0.5,alice\nbpw1qbbbbbbbbbbbbbb\nbpw1qcccccccccccccc,2,bob and carol\n", FormatCSV},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadRecipients(writeRecipients(t, tt.name, tt.content), tt.format, 0.001)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+			if total := recipientsTotal(got); total != 2.501 {
+				t.Errorf("total = %v, want 2.501", total)
+			}
+		})
+	}
+}
+
+func TestReadRecipientsInvalid(t *testing.T) {
+	path := writeRecipients(t, "bad.txt", `bpw1qaaaaaaaaaaaaaa 0.5
+bpw1qbbbbbbbbbbbbbb abc
+bpw1qcccccccccccccc -1
+bpw1qaaaaaaaaaaaaaa 0.1
+bpw1qdddddddddddddd 0.123456789
+bpw1qeeeeeeeeeeeeee 30000000
+`)
+	_, err := ReadRecipients(path, "", 0.001)
+	if err == nil {
+		t.Fatal("want error")
+	}
+	for _, want := range []string{
+		`line 2: invalid amount "abc"`,
+		"line 3: amount must be positive, got -1",
+		"duplicate address bpw1qaaaaaaaaaaaaaa, first at recipient 1",
+		"more than 8 decimal places",
+		"exceeds 21000000 BTCW",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+
+	// 没有金额且未配置默认金额
+	if _, err := ReadRecipients(writeRecipients(t, "a.csv", "bpw1qaaaaaaaaaaaaaa\n"), "", 0); err == nil || !strings.Contains(err.Error(), "amount must be positive") {
+		t.Errorf("err = %v", err)
+	}
+}
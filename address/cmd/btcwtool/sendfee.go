@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"address/rpc"
+)
+
+// sendmany 的费率来源
+const (
+	FeeModeFixed      = "feerate"          // 使用 sendmany.feerate（默认）
+	FeeModeConfTarget = "confTarget"       // 由节点钱包按 conf_target 和 estimate_mode 估算
+	FeeModeEstimate   = StrategyEstimate   // 使用 estimatesmartfee 的费率，不低于 minFeerate
+	FeeModePercentile = StrategyPercentile // 使用交易池费率的第 percentile 百分位，不低于 minFeerate
+)
+
+// defaultMaxFeerate 是 maxFeerate 和 bumpfee.feeCap 都未设置时的费率上限（sat/vB），
+// 与节点 -maxfeerate 的默认值 0.10 BTCW/kvB 相同
+const defaultMaxFeerate = 10000
+
+// SendFeeConfig 决定 sendmany 每笔交易的费率，每笔交易发送前重新估算
+type SendFeeConfig struct {
+	// Mode 费率来源：feerate、confTarget、estimatesmartfee 或 percentile，默认 feerate
+	Mode string `yaml:"mode"`
+	// ConfTarget 确认目标（区块数），默认 2
+	ConfTarget int `yaml:"confTarget"`
+	// EstimateMode 估算模式：economical 或 conservative，留空使用节点默认值
+	EstimateMode string `yaml:"estimateMode"`
+	// Percentile percentile 模式使用的交易池费率百分位，默认 50
+	Percentile float64 `yaml:"percentile"`
+	// MinFeerate 是估算费率的下限（sat/vB），为 0 时不限制
+	MinFeerate float64 `yaml:"minFeerate"`
+	// MaxFeerate 是费率上限（sat/vB），选择的费率超过上限时这笔交易失败，默认使用 bumpfee.feeCap
+	MaxFeerate float64 `yaml:"maxFeerate"`
+}
+
+// validFeeMode 返回 mode 是否是支持的费率来源
+func validFeeMode(mode string) bool {
+	return mode == "" || mode == FeeModeFixed || mode == FeeModeConfTarget || mode == FeeModeEstimate || mode == FeeModePercentile
+}
+
+// validate 检查选项
+func (c SendFeeConfig) validate() error {
+	var errs []error
+	if !validFeeMode(c.Mode) {
+		errs = append(errs, fmt.Errorf("mode: must be %q, %q, %q or %q, got %q", FeeModeFixed, FeeModeConfTarget, FeeModeEstimate, FeeModePercentile, c.Mode))
+	}
+	if c.ConfTarget < 0 {
+		errs = append(errs, fmt.Errorf("confTarget: must not be negative, got %d", c.ConfTarget))
+	}
+	if !validEstimateMode(c.EstimateMode) {
+		errs = append(errs, fmt.Errorf("estimateMode: must be \"economical\" or \"conservative\", got %q", c.EstimateMode))
+	}
+	if c.Percentile < 0 || c.Percentile > 100 {
+		errs = append(errs, fmt.Errorf("percentile: must be between 0 and 100, got %v", c.Percentile))
+	}
+	if c.MinFeerate < 0 || c.MaxFeerate < 0 || c.maxFeerate() < c.MinFeerate {
+		errs = append(errs, fmt.Errorf("minFeerate and maxFeerate: must not be negative and maxFeerate must not be below minFeerate, got %v and %v", c.MinFeerate, c.MaxFeerate))
+	}
+	return errors.Join(errs...)
+}
+
+// maxFeerate 返回费率上限（sat/vB）
+func (c SendFeeConfig) maxFeerate() float64 {
+	if c.MaxFeerate > 0 {
+		return c.MaxFeerate
+	}
+	return defaultMaxFeerate
+}
+
+// estimated 返回费率是否在发送前由本工具估算
+func (c SendFeeConfig) estimated() bool {
+	return c.Mode == FeeModeEstimate || c.Mode == FeeModePercentile
+}
+
+// sendFee 是一笔交易使用的费率，Rate 为 0 时由节点钱包按 ConfTarget 和 EstimateMode 估算
+type sendFee struct {
+	Rate         float64 // sat/vB
+	ConfTarget   int
+	EstimateMode string
+	Source       string // 费率的来源，用于日志
+}
+
+func (f sendFee) String() string {
+	if f.Rate == 0 {
+		return f.Source
+	}
+	return fmt.Sprintf("%.3f sat/vB (%s)", f.Rate, f.Source)
+}
+
+// fundOptions 返回 fundrawtransaction 和 walletcreatefundedpsbt 的费率选项
+func (f sendFee) fundOptions() *rpc.FundRawTransactionOptions {
+	return &rpc.FundRawTransactionOptions{FeeRate: f.Rate, ConfTarget: f.ConfTarget, EstimateMode: f.EstimateMode}
+}
+
+// resolve 返回下一笔交易的费率，feerate 是 sendmany.feerate。估算失败时使用 feerate，未设置时使用 MinFeerate。
+// 选择的费率超过 maxFeerate 时返回错误；confTarget 模式用 estimatesmartfee 预先检查节点钱包可能选择的费率
+func (c SendFeeConfig) resolve(ctx context.Context, source feeSource, feerate int) (sendFee, error) {
+	fee, err := c.choose(ctx, source, feerate)
+	if err != nil {
+		return sendFee{}, err
+	}
+	if maxFeerate := c.maxFeerate(); fee.Rate > maxFeerate {
+		return sendFee{}, fmt.Errorf("fee rate %s exceeds maxFeerate %v sat/vB", fee, maxFeerate)
+	}
+	// 估算失败时节点钱包也无法估算，由节点决定
+	if fee.Rate == 0 {
+		estimate := estimateStrategy{source: source, confTarget: fee.ConfTarget, mode: fee.EstimateMode}
+		if rate, err := estimate.NextFeerate(ctx, 0); err == nil && rate > c.maxFeerate() {
+			return sendFee{}, fmt.Errorf("%s: estimatesmartfee %.3f sat/vB exceeds maxFeerate %v sat/vB", fee, rate, c.maxFeerate())
+		}
+	}
+	return fee, nil
+}
+
+// choose 按 Mode 选择下一笔交易的费率
+func (c SendFeeConfig) choose(ctx context.Context, source feeSource, feerate int) (sendFee, error) {
+	confTarget := c.ConfTarget
+	if confTarget == 0 {
+		confTarget = defaultConfTarget
+	}
+	var strategy FeeStrategy
+	var name string
+	switch c.Mode {
+	case FeeModeConfTarget:
+		return sendFee{ConfTarget: confTarget, EstimateMode: c.EstimateMode, Source: strings.TrimSpace(fmt.Sprintf("node wallet estimate for conf_target %d %s", confTarget, c.EstimateMode))}, nil
+	case FeeModeEstimate:
+		strategy = estimateStrategy{source: source, confTarget: confTarget, mode: c.EstimateMode}
+		name = strings.TrimSpace(fmt.Sprintf("estimatesmartfee %d %s", confTarget, c.EstimateMode))
+	case FeeModePercentile:
+		p := c.Percentile
+		if p == 0 {
+			p = defaultPercentile
+		}
+		strategy = percentileStrategy{source: source, percentile: p}
+		name = fmt.Sprintf("mempool percentile %v", p)
+	default:
+		return sendFee{Rate: float64(feerate), Source: "feerate"}, nil
+	}
+
+	rate, err := strategy.NextFeerate(ctx, 0)
+	if err != nil {
+		switch {
+		case feerate > 0:
+			return sendFee{Rate: float64(feerate), Source: fmt.Sprintf("feerate, %s failed: %v", name, err)}, nil
+		case c.MinFeerate > 0:
+			return sendFee{Rate: c.MinFeerate, Source: fmt.Sprintf("minFeerate, %s failed: %v", name, err)}, nil
+		}
+		return sendFee{}, fmt.Errorf("%s: %w", name, err)
+	}
+	fee := sendFee{Rate: rate, Source: fmt.Sprintf("%s %.3f sat/vB", name, rate)}
+	if c.MinFeerate > 0 && rate < c.MinFeerate {
+		fee.Rate = c.MinFeerate
+		fee.Source += ", raised to minFeerate"
+	}
+	// fee_rate 最多 3 位小数
+	fee.Rate = math.Round(fee.Rate*1000) / 1000
+	return fee, nil
+}
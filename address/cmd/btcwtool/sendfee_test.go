@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"address/rpc"
+)
+
+func TestSendFeeResolve(t *testing.T) {
+	mempool := map[string]rpc.MempoolEntry{"a": mempoolTx(5), "b": mempoolTx(40), "c": mempoolTx(12), "d": mempoolTx(20)}
+	source := fakeFeeSource{smartFee: &rpc.SmartFee{FeeRate: 0.00031234, Blocks: 6}, mempool: mempool}
+	failing := fakeFeeSource{err: errors.New("connection refused")}
+	tests := []struct {
+		config   SendFeeConfig
+		source   feeSource
+		feerate  int
+		want     sendFee
+		contains string // Source 包含的内容
+	}{
+		{config: SendFeeConfig{}, source: source, feerate: 5, want: sendFee{Rate: 5}, contains: "feerate"},
+		{config: SendFeeConfig{Mode: FeeModeConfTarget, ConfTarget: 6, EstimateMode: "economical"}, source: source, feerate: 5,
+			want: sendFee{ConfTarget: 6, EstimateMode: "economical"}, contains: "conf_target 6 economical"},
+		{config: SendFeeConfig{Mode: FeeModeEstimate, ConfTarget: 6}, source: source, want: sendFee{Rate: 31.234}, contains: "estimatesmartfee 6 31.234"},
+		{config: SendFeeConfig{Mode: FeeModePercentile, MinFeerate: 15}, source: source, want: sendFee{Rate: 15}, contains: "mempool percentile 50 12.000 sat/vB, raised to minFeerate"},
+		{config: SendFeeConfig{Mode: FeeModePercentile, Percentile: 90}, source: source, want: sendFee{Rate: 40}, contains: "mempool percentile 90"},
+		// 估算失败时使用 feerate，未设置时使用 minFeerate
+		{config: SendFeeConfig{Mode: FeeModeEstimate, MinFeerate: 2}, source: failing, feerate: 5, want: sendFee{Rate: 5}, contains: "feerate, estimatesmartfee 2 failed: connection refused"},
+		{config: SendFeeConfig{Mode: FeeModePercentile, MinFeerate: 2}, source: failing, want: sendFee{Rate: 2}, contains: "minFeerate, mempool percentile 50 failed"},
+	}
+	for _, tt := range tests {
+		got, err := tt.config.resolve(context.Background(), tt.source, tt.feerate)
+		source := got.Source
+		got.Source = ""
+		if err != nil || got != tt.want || !strings.Contains(source, tt.contains) {
+			t.Errorf("%+v: resolve = %+v (%s), %v, want %+v (%s)", tt.config, got, source, err, tt.want, tt.contains)
+		}
+	}
+
+	if _, err := (SendFeeConfig{Mode: FeeModeEstimate}).resolve(context.Background(), failing, 0); err == nil {
+		t.Errorf("resolve without fallback succeeded")
+	}
+
+	// 超过费率上限时失败，未设置 maxFeerate 时上限为 defaultMaxFeerate
+	expensive := fakeFeeSource{smartFee: &rpc.SmartFee{FeeRate: 0.2, Blocks: 2}, mempool: mempool}
+	for _, tt := range []struct {
+		config  SendFeeConfig
+		source  feeSource
+		feerate int
+	}{
+		{config: SendFeeConfig{Mode: FeeModeEstimate, MaxFeerate: 20}, source: source},
+		{config: SendFeeConfig{Mode: FeeModeEstimate}, source: expensive},
+		{config: SendFeeConfig{}, source: source, feerate: defaultMaxFeerate + 1},
+		{config: SendFeeConfig{Mode: FeeModeConfTarget, MaxFeerate: 20}, source: source},
+	} {
+		if got, err := tt.config.resolve(context.Background(), tt.source, tt.feerate); err == nil || !strings.Contains(err.Error(), "exceeds maxFeerate") {
+			t.Errorf("%+v with feerate %d: resolve = %+v, %v, want maxFeerate error", tt.config, tt.feerate, got, err)
+		}
+	}
+	// confTarget 模式估算失败时由节点钱包决定
+	if _, err := (SendFeeConfig{Mode: FeeModeConfTarget, MaxFeerate: 20}).resolve(context.Background(), failing, 0); err != nil {
+		t.Errorf("confTarget without estimate: %v", err)
+	}
+}
+
+func TestSendFeeValidate(t *testing.T) {
+	if err := (SendFeeConfig{Mode: FeeModePercentile, MinFeerate: 1, MaxFeerate: 50}).validate(); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+	err := SendFeeConfig{Mode: "fast", EstimateMode: "quick", Percentile: 101, MinFeerate: 10, MaxFeerate: 5}.validate()
+	for _, want := range []string{"mode:", "estimateMode:", "percentile:", "maxFeerate must not be below minFeerate"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want %q", err, want)
+		}
+	}
+	if err := (SendFeeConfig{MinFeerate: defaultMaxFeerate + 1}).validate(); err == nil {
+		t.Errorf("minFeerate above default maxFeerate accepted")
+	}
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"address/rpc"
+
+	"go.uber.org/zap"
+)
+
+// SendManyConfig sendmany 子命令的配置
+type SendManyConfig struct {
+	Node string `yaml:"node"`
+	// Wallets 选择发送的钱包
+	Wallets     WalletFilter `yaml:"wallets"`
+	AddressFile string       `yaml:"addressFile"`
+	// AddressFormat 是地址文件格式 json、csv 或 text，为空时按扩展名判断
+	AddressFormat string `yaml:"addressFormat"`
+	// AddressLimit 只向文件中前 addressLimit 个地址付款，为 0 时不限制
+	AddressLimit int `yaml:"addressLimit"`
+	// MaxTxVsize 是每笔交易估算的虚拟大小上限，地址多时分成多笔交易，默认 99000
+	MaxTxVsize int `yaml:"maxTxVsize"`
+	// EstimatedInputs 是估算交易大小时每笔交易预留的输入数，默认 10
+	EstimatedInputs int `yaml:"estimatedInputs"`
+	// CoinControl 限制交易花费的 UTXO，设置后自行选择输入构建交易
+	CoinControl CoinControlConfig `yaml:"coinControl"`
+	// Amounts 是地址文件中没有金额的地址收到的数量
+	Amounts float64 `yaml:"amounts"`
+	// Feerate 是交易费率（sat/vB），为 0 时由节点钱包决定
+	Feerate int `yaml:"feerate"`
+	// Fee 选择费率来源：固定的 feerate、节点按确认目标估算、estimatesmartfee 或交易池百分位
+	Fee    SendFeeConfig `yaml:"fee"`
+	IsSend bool          `yaml:"isSend"`
+	// MaxSendCount 是发送的交易数，多于批数时从第一批开始重复，为 0 时每批发送一次
+	MaxSendCount int `yaml:"maxSendCount"`
+	// MempoolLimits 是节点的交易池链限制，花费未确认输出的交易会超过限制时跳过该钱包
+	MempoolLimits MempoolLimits `yaml:"mempoolLimits"`
+	SleepSec      int           `yaml:"sleepSec"`
+	// Concurrency 同时处理的钱包数，默认 4
+	Concurrency int `yaml:"concurrency"`
+	// PSBT 只创建 PSBT，签名后再广播，需要设置 journalFile
+	PSBT PSBTConfig `yaml:"psbt"`
+	// JournalFile 记录每笔交易付款批次和结果的文件，重新运行时跳过已发送的交易，为空时不记录
+	JournalFile string `yaml:"journalFile"`
+}
+
+// sendmanyCommand 用于调用sendmany批量付款，地址超过单笔交易容量（约 2919 addresses，99405vB）时自动分成多笔交易，不要用正在挖矿的节点执行，会卡住
+var sendmanyCommand = &command{
+	name:  "sendmany",
+	usage: "read addresses and amounts from JSON, CSV or text then use sendmany to send btcw to them",
+	flags: func(fs *flag.FlagSet, config *Config) {
+		c := &config.SendMany
+		fs.StringVar(&c.AddressFile, "address-file", c.AddressFile, "JSON, CSV or text file of recipient addresses with optional amounts and labels")
+		fs.StringVar(&c.AddressFormat, "address-format", c.AddressFormat, "address file format: json, csv or text (default: by file extension)")
+		fs.IntVar(&c.AddressLimit, "address-limit", c.AddressLimit, "only pay the first N addresses of the address file (0: all)")
+		fs.IntVar(&c.MaxTxVsize, "max-tx-vsize", c.MaxTxVsize, "split recipients into transactions of at most this many vB")
+		fs.Float64Var(&c.Amounts, "amount", c.Amounts, "BTCW sent to each address without an amount in the address file")
+		fs.IntVar(&c.Feerate, "fee-rate", c.Feerate, "fee rate in sat/vB")
+		fs.StringVar(&c.Fee.Mode, "fee-mode", c.Fee.Mode, "fee rate source: feerate, confTarget, estimatesmartfee or percentile")
+		fs.IntVar(&c.Fee.ConfTarget, "conf-target", c.Fee.ConfTarget, "confirmation target in blocks for the confTarget and estimatesmartfee fee modes")
+		fs.BoolVar(&c.IsSend, "send", c.IsSend, "actually broadcast transactions")
+		fs.IntVar(&c.MaxSendCount, "max-send-count", c.MaxSendCount, "number of sendmany transactions to make (0: one per batch)")
+		fs.IntVar(&c.SleepSec, "sleep", c.SleepSec, "seconds to wait between rounds")
+		c.Wallets.register(fs)
+		fs.IntVar(&c.Concurrency, "concurrency", c.Concurrency, "number of wallets processed concurrently")
+		fs.StringVar(&c.JournalFile, "journal-file", c.JournalFile, "payout journal; a rerun skips transactions already sent")
+	},
+	run: runSendMany,
+}
+
+func runSendMany(app *App) error {
+	config := app.Config.SendMany
+	sugar := app.Sugar
+	ctx := app.Ctx
+	isSend := config.IsSend && !app.Flags.DryRun
+
+	node, err := app.Node(config.Node, RoleWallet)
+	if err != nil {
+		return err
+	}
+	client := node.Client
+	sugar.Infof("")
+	sugar.Infof("Starting sendmany, RPC server: %s", client.URL())
+	sugar.Infof("Sending to wallet: %s", config.AddressFile)
+
+	// 调用 listwallets RPC
+	wallets, err := node.Wallets(ctx, config.Wallets)
+	if err != nil {
+		return fmt.Errorf("error listing wallets: %w", err)
+	}
+	sugar.Infof("Node load wallet(s):%s", wallets)
+
+	// 从文件中读取地址和金额，设置了 addressLimit 时只向前 addressLimit 个地址付款
+	recipients, err := ReadRecipients(config.AddressFile, config.AddressFormat, config.Amounts)
+	if err != nil {
+		return fmt.Errorf("error reading addresses: %w", err)
+	}
+	if config.AddressLimit > 0 && len(recipients) > config.AddressLimit {
+		recipients = recipients[:config.AddressLimit]
+	}
+	if err := validateRecipients(ctx, client, recipients); err != nil {
+		return fmt.Errorf("error validating addresses: %w", err)
+	}
+
+	// 按交易大小上限把地址分成多笔交易
+	maxTxVsize := config.MaxTxVsize
+	if maxTxVsize <= 0 {
+		maxTxVsize = defaultMaxTxVsize
+	}
+	// 限制了输入数时按上限估算
+	estimatedInputs := config.EstimatedInputs
+	if estimatedInputs <= 0 && config.CoinControl.MaxInputs > 0 {
+		estimatedInputs = config.CoinControl.MaxInputs
+	}
+	if estimatedInputs <= 0 {
+		estimatedInputs = defaultEstimatedInputs
+	}
+	batches, err := splitRecipients(recipients, maxTxVsize, estimatedInputs)
+	if err != nil {
+		return err
+	}
+	if len(batches) == 0 {
+		return fmt.Errorf("no recipients in %s", config.AddressFile)
+	}
+	maxSendCount := config.MaxSendCount
+	if maxSendCount <= 0 {
+		maxSendCount = len(batches)
+	}
+	total := recipientsTotal(recipients)
+	sugar.Infof("Recipients: %d addresses, %.8f BTCW in %d batches of at most %d vB, %d transactions", len(recipients), total, len(batches), maxTxVsize, maxSendCount)
+	maxBatchVSize := 0
+	for _, batch := range batches {
+		sugar.Infof("Batch %d: %d addresses, %.8f BTCW, ~%d vB", batch.Index+1, len(batch.Recipients), recipientsTotal(batch.Recipients), batch.VSize)
+		maxBatchVSize = max(maxBatchVSize, batch.VSize)
+	}
+	if mode := config.Fee.Mode; mode != "" && mode != FeeModeFixed {
+		sugar.Infof("Fee mode: %s, confTarget: %d, estimateMode: %q, percentile: %v, minFeerate: %v sat/vB (0: no limit)",
+			mode, config.Fee.ConfTarget, config.Fee.EstimateMode, config.Fee.Percentile, config.Fee.MinFeerate)
+	}
+	sugar.Infof("Fee rate cap: %v sat/vB, batches with a higher fee rate fail", config.Fee.maxFeerate())
+	if cc := config.CoinControl; cc.enabled() {
+		sugar.Infof("Coin control: confirmed UTXOs of at least %.8f BTCW, excluding labels %v, at most %d inputs (0: no limit)", cc.MinUtxoAmount, cc.ExcludeLabels, cc.MaxInputs)
+	}
+
+	// 付款日志记录每笔交易付款的批次和结果，重新运行时跳过已发送的交易
+	journal, err := openJournal(config.JournalFile, batches, !isSend)
+	if err != nil {
+		return err
+	}
+	if err := journal.reconcile(ctx, client, sugar); err != nil {
+		return err
+	}
+	if done := journal.count(PayoutSent); done > 0 {
+		sugar.Infof("Journal %s: %d / %d transactions already sent, resuming", config.JournalFile, done, maxSendCount)
+	}
+
+	// PSBT 流程中等待签名的交易也算作已完成，签名后在每轮开始时广播
+	var psbt *psbtSender
+	if config.PSBT.enabled() {
+		psbt = &psbtSender{config: config.PSBT, client: client, journal: journal, sugar: sugar}
+		if config.PSBT.Signer != "" {
+			if psbt.signer, err = app.NamedNode(config.PSBT.Signer, RoleWallet); err != nil {
+				return err
+			}
+		}
+		sugar.Infof("PSBT mode: writing PSBTs to %s, signer: %q", config.PSBT.Dir, config.PSBT.Signer)
+		if isSend {
+			psbt.lockUnsigned(ctx)
+			psbt.broadcastAll(ctx)
+		}
+	}
+
+	// 新区块确认交易后未确认交易的大小减少，收到通知时立即开始下一轮
+	notifier := node.Notifier(app.Shutdown, sugar, TopicHashBlock)
+	// 各钱包并发发送，发送前先在日志中占用下一笔交易，保证总数不超过 maxSendCount；收到退出信号后不再发送。
+	// 发送失败的交易由之后的钱包重发，交易数多于批数时从第一批开始重复
+	var mu sync.Mutex
+	rounds, failed := 0, 0
+	previews := make(map[int]*dryRunResult) // 试运行的预览，以 Send 为键
+	defer func() {
+		sugar.Infof("sendmany summary: %d rounds, %d / %d transactions made, %d failed", rounds, journal.count(PayoutSent), maxSendCount, failed)
+		if !isSend {
+			summarizeDryRun(sugar, journal, previews)
+			return
+		}
+		for _, e := range journal.Entries {
+			switch e.Status {
+			case PayoutSent:
+				sugar.Infof("Batch %d (%d addresses) paid by %s from wallet %s", e.Batch+1, len(e.Recipients), e.TxID, e.Wallet)
+			case PayoutUnsigned:
+				sugar.Infof("Batch %d (%d addresses) waiting for signed PSBT %s", e.Batch+1, len(e.Recipients), signedPSBTFile(e.PSBT))
+			case PayoutPending:
+				sugar.Warnf("Batch %d (%d addresses) from wallet %s is pending, check the wallet before sending it again", e.Batch+1, len(e.Recipients), e.Wallet)
+			}
+		}
+	}()
+	for journal.count(PayoutSent, PayoutUnsigned) < maxSendCount && app.Shutdown.Err() == nil {
+		rounds++
+		// 上一轮结果未知的交易先到钱包中核对，避免重复付款
+		if err := journal.reconcile(ctx, client, sugar); err != nil {
+			sugar.Warnf("%v", err)
+		}
+		// 无法核对的交易不会重发，其余交易都已完成时停止，由操作员处理
+		if pending := journal.count(PayoutPending); pending > 0 && journal.count(PayoutSent, PayoutUnsigned, PayoutPending) >= maxSendCount {
+			sugar.Warnf("%d pending transactions could not be checked, resolve them in journal %s and run again", pending, config.JournalFile)
+			return nil
+		}
+		// 记录开始发送时的区块高度，结果未知时从这里开始核对
+		height, err := client.GetBlockCount(ctx)
+		if err != nil {
+			sugar.Warnf("Error getting block count, pending transactions will be checked against the whole wallet history: %v", err)
+		}
+		if psbt != nil && isSend {
+			psbt.broadcastAll(ctx)
+		}
+		made := journal.count(PayoutSent)
+		err = forEachWallet(ctx, sugar, wallets, config.Concurrency, func(ctx context.Context, walletName string, sugar *zap.SugaredLogger) error {
+			sugar.Infof("Processing wallet: %s", walletName)
+			walletClient := client.Wallet(walletName)
+			// 钱包会花费自己发出的未确认找零，按最大一批估算新交易，检查花费这些输出后是否超过交易池链限制；
+			// coinControl 只花费已确认的 UTXO，不受链限制
+			if !config.CoinControl.enabled() {
+				unspent, err := walletClient.ListUnspent(ctx, 0, 0, nil, false, nil)
+				if err != nil {
+					return fmt.Errorf("error listing unspent: %w", err)
+				}
+				parents := make([]string, len(unspent))
+				for i, u := range unspent {
+					parents[i] = u.TxID
+				}
+				chain, err := newMempoolChain(ctx, client, parents, maxBatchVSize)
+				if err != nil {
+					sugar.Errorf("Error checking mempool chain for wallet %s: %v", walletName, err)
+					return nil
+				}
+				if reason := chain.violation(config.MempoolLimits); reason != "" {
+					sugar.Infof("Next transaction from wallet %s would have %s, skipping sendmany", walletName, reason)
+					for _, u := range unspent {
+						sugar.Infof("Skip, unconfirmed UTXO in wallet %s: txid: %s, ancestors=%d, ancestor size=%d vB", walletName, u.TxID, u.AncestorCount, u.AncestorSize)
+					}
+					return nil
+				}
+				sugar.Debugf("Next transaction from wallet %s: %d ancestors (%d vB), %d descendants (%d vB)", walletName, chain.AncestorCount, chain.AncestorSize, chain.DescendantCount, chain.DescendantSize)
+			}
+
+			if app.Shutdown.Err() != nil {
+				return nil
+			}
+			// 每笔交易发送前重新选择费率
+			fee, err := config.Fee.resolve(ctx, client, config.Feerate)
+			if err != nil {
+				mu.Lock()
+				failed++
+				mu.Unlock()
+				sugar.Errorf("Error choosing fee rate for wallet %s: %v", walletName, err)
+				return nil
+			}
+			entry, err := journal.reserve(maxSendCount, walletName, height)
+			if err != nil || entry == nil {
+				return err
+			}
+			batch := &batches[entry.Batch]
+			sugar.Infof("Fee rate for batch %d from wallet %s: %s", batch.Index+1, walletName, fee)
+			if psbt != nil && isSend {
+				if err := psbt.create(ctx, walletClient, entry, batch, config.CoinControl, fee); err != nil {
+					mu.Lock()
+					failed++
+					mu.Unlock()
+					sugar.Warnf("Error with PSBT for batch %d from wallet %s: %v", batch.Index+1, walletName, err)
+				}
+				return nil
+			}
+			txid := ""
+			if isSend {
+				var err error
+				if config.CoinControl.enabled() {
+					txid, err = config.CoinControl.sendCoinControl(ctx, walletClient, batch, fee, func(txid string) error {
+						return journal.prepare(entry, txid)
+					})
+				} else {
+					var sendManyResult *rpc.SendManyResult
+					sendManyResult, err = walletClient.SendMany(ctx, batch.amounts(), rpc.SendManyOptions{
+						Minconf: 1, Comment: journal.comment(entry.Send), FeeRate: fee.Rate, ConfTarget: fee.ConfTarget, EstimateMode: fee.EstimateMode,
+					})
+					if err == nil {
+						txid = sendManyResult.TxID
+						sugar.Infof("Node fee reason for batch %d: %s", batch.Index+1, sendManyResult.FeeReason)
+					}
+				}
+				if err != nil {
+					mu.Lock()
+					failed++
+					mu.Unlock()
+					sugar.Warnf("Error sending batch %d from wallet %s: %v", batch.Index+1, walletName, err)
+					return journal.finish(entry, "", err)
+				}
+				sugar.Infof("Send batch %d from wallet %s: txid: %s", batch.Index+1, walletName, txid)
+				logRecipients(sugar, batch.Recipients, txid)
+			} else {
+				signerClient, _, err := psbt.signerClient(walletName)
+				if err != nil {
+					return err
+				}
+				result, err := simulateSend(ctx, client, walletClient, signerClient, batch, config.CoinControl, fee)
+				if err != nil {
+					mu.Lock()
+					failed++
+					mu.Unlock()
+					sugar.Warnf("Dry run of batch %d from wallet %s failed: %v", batch.Index+1, walletName, err)
+					return journal.finish(entry, "", notSent(err))
+				}
+				sugar.Infof("Dry run batch %d from wallet %s: %s", batch.Index+1, walletName, result)
+				mu.Lock()
+				previews[entry.Send] = result
+				mu.Unlock()
+				txid = result.TxID
+			}
+			if err := journal.finish(entry, txid, nil); err != nil {
+				return err
+			}
+			sugar.Infof("Made transaction: %d / %d", journal.count(PayoutSent), maxSendCount)
+			return nil
+		})
+		if err != nil {
+			sugar.Error("Error processing wallets", zap.Error(err))
+		}
+		if journal.count(PayoutSent, PayoutUnsigned) >= maxSendCount {
+			sugar.Infof("Created enough transaction, exiting...")
+			return nil
+		}
+		// 试运行不改变钱包，不需要等待；一轮没有进展时之后也不会有
+		if !isSend {
+			if journal.count(PayoutSent) == made {
+				sugar.Infof("Dry run: no wallet can fund the remaining transactions")
+				return nil
+			}
+			continue
+		}
+		// 每轮之间等待
+		if n, ok := notifier.Wait(app.Shutdown, time.Duration(config.SleepSec)*time.Second); ok {
+			sugar.Infof("ZMQ block notification: %s", n.BlockHash())
+		}
+	}
+	if app.Shutdown.Err() != nil {
+		sugar.Infof("Stopped before making %d transactions", maxSendCount)
+	}
+	return nil
+}
+
+// logRecipients 记录支付每个收款地址的 txid，便于按地址查找付款
+func logRecipients(sugar *zap.SugaredLogger, recipients []Recipient, txid string) {
+	for _, r := range recipients {
+		sugar.Infof("Recipient %s %s paid %.8f BTCW by %s", r.Address, r.Label, r.Amount, txid)
+	}
+}
+
+// summarizeDryRun 报告试运行中每个钱包和批次的预览，以及总大小和手续费
+func summarizeDryRun(sugar *zap.SugaredLogger, journal *payoutJournal, previews map[int]*dryRunResult) {
+	var vsize, rejected int
+	var fee float64
+	for _, e := range journal.Entries {
+		result := previews[e.Send]
+		if e.Status != PayoutSent || result == nil {
+			continue
+		}
+		sugar.Infof("Batch %d (%d addresses, %.8f BTCW) from wallet %s: %s", e.Batch+1, len(e.Recipients), e.Amount, e.Wallet, result)
+		vsize += result.VSize
+		fee += result.Fee
+		if !result.Allowed {
+			rejected++
+		}
+	}
+	sugar.Infof("Dry run total: %d transactions, %d vB, fee %.8f BTCW, %d would not pass testmempoolaccept", len(previews), vsize, fee, rejected)
+}
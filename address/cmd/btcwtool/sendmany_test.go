@@ -0,0 +1,671 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"address/rpc"
+	"address/rpc/rpctest"
+)
+
+// setupSendMany 创建两个有余额的付款钱包，并把收款钱包的 n 个地址写入地址文件
+func setupSendMany(t *testing.T, s *rpctest.Server, n int) (addressFile string, addresses []string) {
+	t.Helper()
+	for _, name := range []string{"payer1", "payer2"} {
+		s.CreateWallet(name)
+		s.Fund(name, 1)
+	}
+	s.Mine(1)
+	s.CreateWallet("payee")
+	for i := 0; i < n; i++ {
+		addresses = append(addresses, s.NewAddress("payee", ""))
+	}
+	received, err := s.Client().Wallet("payee").ListReceivedByAddress(context.Background(), 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(received)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addressFile = filepath.Join(t.TempDir(), "addresses.json")
+	if err := os.WriteFile(addressFile, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return addressFile, addresses
+}
+
+func sendManyTestConfig(addressFile string) SendManyConfig {
+	return SendManyConfig{
+		AddressFile:  addressFile,
+		AddressLimit: 3,
+		Amounts:      0.001,
+		Feerate:      5,
+		IsSend:       true,
+		MaxSendCount: 2,
+	}
+}
+
+func TestSendMany(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	addressFile, addresses := setupSendMany(t, s, 4)
+	app := newTestApp(t, s, "payer1", "payer2")
+	app.Config.SendMany = sendManyTestConfig(addressFile)
+
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+	mempool := s.Mempool()
+	if len(mempool) != 2 {
+		t.Fatalf("mempool has %d transactions, want 2", len(mempool))
+	}
+	for _, txid := range mempool {
+		tx, _ := s.Tx(txid)
+		if tx.FeeRate() != 5 {
+			t.Errorf("tx %s feerate %v, want 5", txid, tx.FeeRate())
+		}
+	}
+	// 只向前 addressLimit 个地址付款
+	for i, address := range addresses {
+		want := 0.002
+		if i >= 3 {
+			want = 0
+		}
+		if got := s.Received(address); got != want {
+			t.Errorf("address %d received %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestSendManySkipsWalletOverChainLimit(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	addressFile, addresses := setupSendMany(t, s, 3)
+	// payer1 已有两笔相连的未确认交易，花费第二笔的找零时有 3 个祖先（含自身）
+	payer1 := s.Client().Wallet("payer1")
+	var chain []string
+	for i := 0; i < 2; i++ {
+		pending, err := payer1.SendMany(context.Background(), map[string]float64{addresses[i]: 0.01}, rpc.SendManyOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		chain = append(chain, pending.TxID)
+	}
+	app := newTestApp(t, s, "payer1", "payer2")
+	app.Config.SendMany = sendManyTestConfig(addressFile)
+	app.Config.SendMany.MaxSendCount = 1
+	app.Config.SendMany.MempoolLimits = MempoolLimits{AncestorCount: 2}
+
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+	mempool := s.Mempool()
+	if len(mempool) != 3 {
+		t.Fatalf("mempool has %d transactions, want 3", len(mempool))
+	}
+	for _, txid := range mempool {
+		if tx, _ := s.Tx(txid); !contains(chain, txid) && tx.Wallet != "payer2" {
+			t.Errorf("tx %s sent from %s, want payer2", txid, tx.Wallet)
+		}
+	}
+	if s.Calls("getmempoolancestors") == 0 {
+		t.Error("getmempoolancestors not called")
+	}
+
+	// 默认限制为 25 个祖先，花费两笔交易的输出都不超过限制
+	c, err := newMempoolChain(context.Background(), s.Client(), append(chain, chain...), 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.AncestorCount != 3 || c.DescendantCount != 3 || c.violation(MempoolLimits{}) != "" {
+		t.Errorf("chain = %+v, violation %q", c, c.violation(MempoolLimits{}))
+	}
+}
+
+func TestSendManyRecipientAmounts(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	_, addresses := setupSendMany(t, s, 3)
+	addressFile := writeRecipients(t, "payouts.csv", "address,amount,label\n"+
+		addresses[0]+",0.25,alice\n"+
+		addresses[1]+",,bob\n"+
+		addresses[2]+",0.0005\n")
+	app := newTestApp(t, s, "payer1")
+	app.Config.SendMany = sendManyTestConfig(addressFile)
+	app.Config.SendMany.MaxSendCount = 1
+
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []float64{0.25, 0.001, 0.0005} {
+		if got := s.Received(addresses[i]); got != want {
+			t.Errorf("address %d received %v, want %v", i, got, want)
+		}
+	}
+
+	// 任一地址无效时不发送
+	addressFile = writeRecipients(t, "bad.txt", addresses[0]+" 0.1\nnot-an-address 0.1\n")
+	app.Config.SendMany.AddressFile = addressFile
+	err := runSendMany(app)
+	if err == nil || !strings.Contains(err.Error(), "recipient 2: invalid address not-an-address") {
+		t.Errorf("err = %v", err)
+	}
+	if n := s.Calls("sendmany"); n != 1 {
+		t.Errorf("sendmany called %d times, want 1", n)
+	}
+}
+
+func TestSendManySplitsRecipients(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	addressFile, addresses := setupSendMany(t, s, 7)
+	// 每个钱包只花费已确认的 UTXO：第一轮 payer1 和 payer2 各发送一批，第二轮只有 payer1 还能发送第三批
+	s.Fund("payer1", 1)
+	s.Mine(1)
+	app := newTestApp(t, s, "payer1", "payer2")
+	app.Config.SendMany = sendManyTestConfig(addressFile)
+	app.Config.SendMany.AddressLimit = 0
+	app.Config.SendMany.Concurrency = 1
+	app.Config.SendMany.MaxSendCount = 0
+	app.Config.SendMany.EstimatedInputs = 1
+	// 每笔交易最多 3 个收款地址加找零
+	app.Config.SendMany.MaxTxVsize = estimateVSize(1, 4, 4*31)
+
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+	mempool := s.Mempool()
+	if len(mempool) != 3 {
+		t.Fatalf("mempool has %d transactions, want 3", len(mempool))
+	}
+	for _, txid := range mempool {
+		if tx, _ := s.Tx(txid); tx.VSize > app.Config.SendMany.MaxTxVsize {
+			t.Errorf("tx %s is %d vB, over %d", txid, tx.VSize, app.Config.SendMany.MaxTxVsize)
+		}
+	}
+	// 每个地址只收到一次
+	for i, address := range addresses {
+		if got := s.Received(address); got != 0.001 {
+			t.Errorf("address %d received %v, want 0.001", i, got)
+		}
+	}
+}
+
+func TestSendManyDryRun(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	addressFile, _ := setupSendMany(t, s, 3)
+	app := newTestApp(t, s, "payer1", "payer2")
+	app.Config.SendMany = sendManyTestConfig(addressFile)
+	app.Flags.DryRun = true
+
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Calls("sendmany"); n != 0 {
+		t.Fatalf("sendmany called %d times in dry run", n)
+	}
+	if n := s.Calls("testmempoolaccept"); n != 2 {
+		t.Errorf("testmempoolaccept called %d times, want 2", n)
+	}
+	if mempool := s.Mempool(); len(mempool) != 0 {
+		t.Errorf("dry run broadcast %v", mempool)
+	}
+
+	// 预览的大小、手续费和找零与实际创建的交易一致
+	batch := &payoutBatch{Recipients: []Recipient{{Address: s.NewAddress("payee", ""), Amount: 0.1}, {Address: s.NewAddress("payee", ""), Amount: 0.2}}}
+	walletClient := s.Client().Wallet("payer1")
+	result, err := simulateSend(context.Background(), s.Client(), walletClient, nil, batch, CoinControlConfig{}, sendFee{Rate: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vsize := 11 + 68 + 3*31 // 模拟节点按每个输入 68 vB、每个输出 31 vB 计算
+	want := dryRunResult{TxID: result.TxID, VSize: vsize, Fee: float64(5*vsize) / 1e8, FeeRate: 5, Inputs: 1, InputTotal: 1, Change: float64(70000000-5*vsize) / 1e8, Allowed: true}
+	if *result != want {
+		t.Errorf("simulateSend = %+v, want %+v", *result, want)
+	}
+	// 非隔离见证输入在 decodepsbt 中只有 non_witness_utxo，输入总额不变
+	var legacy rpctest.Handler
+	legacy = func(_ string, params []json.RawMessage) (interface{}, error) {
+		s.Handle("decodepsbt", nil)
+		defer s.Handle("decodepsbt", legacy)
+		var psbt string
+		if err := json.Unmarshal(params[0], &psbt); err != nil {
+			return nil, err
+		}
+		decoded, err := s.Client().DecodePSBT(context.Background(), psbt)
+		if err != nil {
+			return nil, err
+		}
+		for i := range decoded.Inputs {
+			decoded.Inputs[i].WitnessUTXO = nil
+		}
+		return decoded, nil
+	}
+	s.Handle("decodepsbt", legacy)
+	result, err = simulateSend(context.Background(), s.Client(), walletClient, nil, batch, CoinControlConfig{}, sendFee{Rate: 5})
+	s.Handle("decodepsbt", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.InputTotal != 1 || result.Fee != want.Fee {
+		t.Errorf("legacy inputs: simulateSend = %+v, want input total 1 and fee %v", *result, want.Fee)
+	}
+
+	// 钱包余额不足时试运行报告失败后结束，不等待下一轮
+	app.Config.SendMany.Amounts = 10
+	app.Config.SendMany.SleepSec = 3600
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSendManyShutdown(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	addressFile, _ := setupSendMany(t, s, 3)
+	app := newTestApp(t, s, "payer1", "payer2")
+	app.Config.SendMany = sendManyTestConfig(addressFile)
+	shutdown, cancel := context.WithCancel(context.Background())
+	cancel()
+	app.Shutdown = shutdown
+
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Calls("sendmany"); n != 0 {
+		t.Fatalf("sendmany called %d times after shutdown", n)
+	}
+}
+
+func TestSendManyJournalResume(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	addressFile, addresses := setupSendMany(t, s, 4)
+	journalFile := filepath.Join(t.TempDir(), "journal.json")
+	app := newTestApp(t, s, "payer1", "payer2")
+	app.Config.SendMany = sendManyTestConfig(addressFile)
+	app.Config.SendMany.AddressLimit = 0
+	app.Config.SendMany.MaxSendCount = 0
+	app.Config.SendMany.EstimatedInputs = 1
+	app.Config.SendMany.MaxTxVsize = estimateVSize(1, 3, 3*31)
+	app.Config.SendMany.JournalFile = journalFile
+
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Calls("sendmany"); n != 2 {
+		t.Fatalf("sendmany called %d times, want 2", n)
+	}
+
+	// 已完成的付款不再重复
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Calls("sendmany"); n != 2 {
+		t.Errorf("rerun called sendmany %d more times", n-2)
+	}
+
+	// 模拟发送后、记录结果前崩溃：第一笔在钱包中能找到，第二笔的钱包里找不到，需要重发
+	var journal payoutJournal
+	data, err := os.ReadFile(journalFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &journal); err != nil {
+		t.Fatal(err)
+	}
+	sent := journal.Entries[0].TxID
+	journal.Entries[0].Status, journal.Entries[0].TxID = PayoutPending, ""
+	journal.Entries[1].Status, journal.Entries[1].TxID = PayoutPending, ""
+	journal.Entries[1].Wallet = "payer3"
+	s.CreateWallet("payer3")
+	data, _ = json.Marshal(&journal)
+	if err := os.WriteFile(journalFile, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	s.Mine(1)
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Calls("sendmany"); n != 3 {
+		t.Errorf("sendmany called %d times, want 3", n)
+	}
+	data, _ = os.ReadFile(journalFile)
+	json.Unmarshal(data, &journal)
+	if journal.Entries[0].Status != PayoutSent || journal.Entries[0].TxID != sent {
+		t.Errorf("entry 0 = %+v, want sent by %s", journal.Entries[0], sent)
+	}
+	if journal.Entries[1].Status != PayoutSent || journal.Entries[1].TxID == "" {
+		t.Errorf("entry 1 = %+v, want sent again", journal.Entries[1])
+	}
+	for i, address := range addresses {
+		want := 0.001
+		if i >= 2 {
+			want = 0.002
+		}
+		if got := s.Received(address); got != want {
+			t.Errorf("address %d received %v, want %v", i, got, want)
+		}
+	}
+
+	// 地址文件变化后不能沿用旧的日志
+	app.Config.SendMany.Amounts = 0.002
+	if err := runSendMany(app); err == nil || !strings.Contains(err.Error(), "different recipients") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestSendManyJournalUnresolved(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	addressFile, _ := setupSendMany(t, s, 4)
+	journalFile := filepath.Join(t.TempDir(), "journal.json")
+	app := newTestApp(t, s, "payer1", "payer2")
+	app.Config.SendMany = sendManyTestConfig(addressFile)
+	app.Config.SendMany.AddressLimit = 0
+	app.Config.SendMany.MaxSendCount = 0
+	app.Config.SendMany.EstimatedInputs = 1
+	app.Config.SendMany.MaxTxVsize = estimateVSize(1, 3, 3*31)
+	app.Config.SendMany.JournalFile = journalFile
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+
+	rewrite := func(edit func(entries []*payoutEntry)) []*payoutEntry {
+		t.Helper()
+		var journal payoutJournal
+		data, err := os.ReadFile(journalFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &journal); err != nil {
+			t.Fatal(err)
+		}
+		if edit == nil {
+			return journal.Entries
+		}
+		edit(journal.Entries)
+		data, _ = json.Marshal(&journal)
+		if err := os.WriteFile(journalFile, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return journal.Entries
+	}
+
+	// 第一笔按记录的 txid 用 gettransaction 核对；第二笔的钱包无法查询，保持 pending，不会重发
+	sent := rewrite(func(entries []*payoutEntry) {
+		entries[0].Status = PayoutPending
+		entries[1].Status, entries[1].TxID, entries[1].Wallet = PayoutPending, "", "gone"
+	})[0].TxID
+	s.Mine(1)
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Calls("sendmany"); n != 2 {
+		t.Fatalf("sendmany called %d times, want no resend of the unresolved transaction", n)
+	}
+	entries := rewrite(nil)
+	if entries[0].Status != PayoutSent || entries[0].TxID != sent {
+		t.Errorf("entry 0 = %+v, want sent by %s", entries[0], sent)
+	}
+	if entries[1].Status != PayoutPending {
+		t.Errorf("entry 1 = %+v, want still pending", entries[1])
+	}
+
+	// 钱包中没有记录的 txid 说明节点没有接受这笔交易，重发
+	rewrite(func(entries []*payoutEntry) {
+		entries[1].TxID, entries[1].Wallet = strings.Repeat("ab", 32), "payer1"
+	})
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Calls("sendmany"); n != 3 {
+		t.Fatalf("sendmany called %d times, want 3", n)
+	}
+	if entries := rewrite(nil); entries[1].Status != PayoutSent || entries[1].TxID == strings.Repeat("ab", 32) {
+		t.Errorf("entry 1 = %+v, want sent again", entries[1])
+	}
+}
+
+func TestSendManyCoinControl(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	addressFile, addresses := setupSendMany(t, s, 3)
+	ctx := context.Background()
+	// payer1 另有带 reserve 标签的 UTXO 和粉尘 UTXO，都不能花费
+	reserve := s.NewAddress("payer1", "reserve")
+	dust := s.NewAddress("payer1", "")
+	if _, err := s.Client().Wallet("payer2").SendMany(ctx, map[string]float64{reserve: 0.5, dust: 0.00001}, rpc.SendManyOptions{Minconf: 1}); err != nil {
+		t.Fatal(err)
+	}
+	s.Mine(1)
+	app := newTestApp(t, s, "payer1")
+	app.Config.SendMany = sendManyTestConfig(addressFile)
+	app.Config.SendMany.MaxSendCount = 1
+	app.Config.SendMany.CoinControl = CoinControlConfig{MinUtxoAmount: 0.0001, ExcludeLabels: []string{"reserve"}, MaxInputs: 2}
+
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+	mempool := s.Mempool()
+	if len(mempool) != 1 {
+		t.Fatalf("mempool has %d transactions, want 1", len(mempool))
+	}
+	if tx, _ := s.Tx(mempool[0]); tx.Wallet != "payer1" || tx.FeeRate() != 5 {
+		t.Errorf("unexpected tx %+v", tx)
+	}
+	for _, address := range addresses {
+		if got := s.Received(address); got != 0.001 {
+			t.Errorf("address %s received %v, want 0.001", address, got)
+		}
+	}
+	unspent, err := s.Client().Wallet("payer1").ListUnspent(ctx, 1, 9999999, []string{reserve, dust}, false, nil)
+	if err != nil || len(unspent) != 2 {
+		t.Errorf("reserve and dust UTXOs spent: %+v, %v", unspent, err)
+	}
+}
+
+func TestSendManyPSBT(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	addressFile, addresses := setupSendMany(t, s, 3)
+	ctx := context.Background()
+	dir := t.TempDir()
+	app := newTestApp(t, s, "payer1")
+	app.Config.SendMany = sendManyTestConfig(addressFile)
+	app.Config.SendMany.MaxSendCount = 1
+	app.Config.SendMany.JournalFile = filepath.Join(dir, "journal.json")
+	app.Config.SendMany.PSBT = PSBTConfig{Dir: dir}
+
+	// 没有签名文件时只写出 PSBT，不广播
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+	if mempool := s.Mempool(); len(mempool) != 0 {
+		t.Fatalf("mempool has %d transactions before signing", len(mempool))
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.psbt"))
+	if len(files) != 1 {
+		t.Fatalf("got PSBT files %v, want 1", files)
+	}
+	if n := s.Calls("sendmany"); n != 0 {
+		t.Errorf("sendmany called %d times in PSBT mode", n)
+	}
+
+	// 模拟离线签名
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	processed, err := s.Client().Wallet("payer1").WalletProcessPSBT(ctx, strings.TrimSpace(string(data)), true)
+	if err != nil || !processed.Complete {
+		t.Fatalf("signing PSBT: %+v, %v", processed, err)
+	}
+	if err := os.WriteFile(signedPSBTFile(files[0]), []byte(processed.PSBT), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+	mempool := s.Mempool()
+	if len(mempool) != 1 {
+		t.Fatalf("mempool has %d transactions after signing, want 1", len(mempool))
+	}
+	if tx, _ := s.Tx(mempool[0]); tx.Wallet != "payer1" || tx.FeeRate() != 5 {
+		t.Errorf("unexpected tx %+v", tx)
+	}
+	for _, address := range addresses {
+		if got := s.Received(address); got != 0.001 {
+			t.Errorf("address %s received %v, want 0.001", address, got)
+		}
+	}
+	var journal payoutJournal
+	if data, err = os.ReadFile(app.Config.SendMany.JournalFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &journal); err != nil {
+		t.Fatal(err)
+	}
+	if len(journal.Entries) != 1 || journal.Entries[0].Status != PayoutSent || journal.Entries[0].TxID != mempool[0] {
+		t.Errorf("unexpected journal entries %+v", journal.Entries)
+	}
+}
+
+func TestSendManyPSBTLocksInputs(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	addressFile, _ := setupSendMany(t, s, 3)
+	s.Fund("payer1", 1)
+	s.Mine(1)
+	ctx := context.Background()
+	dir := t.TempDir()
+	app := newTestApp(t, s, "payer1")
+	app.Config.SendMany = sendManyTestConfig(addressFile)
+	app.Config.SendMany.JournalFile = filepath.Join(dir, "journal.json")
+	app.Config.SendMany.PSBT = PSBTConfig{Dir: dir}
+
+	// 同一钱包的两个 PSBT 都在等待签名，不能花费相同的输入
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.psbt"))
+	if len(files) != 2 {
+		t.Fatalf("got PSBT files %v, want 2", files)
+	}
+	spentBy := make(map[rpc.TxInput]string)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := s.Client().DecodePSBT(ctx, strings.TrimSpace(string(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, in := range outpoints(decoded.Tx.Vin) {
+			if other, ok := spentBy[in]; ok {
+				t.Fatalf("%s and %s both spend %s:%d", other, file, in.TxID, in.Vout)
+			}
+			spentBy[in] = file
+		}
+	}
+
+	// 节点重启后锁定丢失，重新运行时重新锁定等待签名的 PSBT 的输入
+	payer := s.Client().Wallet("payer1")
+	if err := payer.LockUnspent(ctx, true, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+	locked, err := payer.ListLockUnspent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locked) != len(spentBy) {
+		t.Fatalf("%d inputs locked after rerun, want %d", len(locked), len(spentBy))
+	}
+	for _, in := range locked {
+		if spentBy[in] == "" {
+			t.Errorf("unexpected locked output %s:%d", in.TxID, in.Vout)
+		}
+	}
+}
+
+func TestSendManyPSBTSigner(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	addressFile, _ := setupSendMany(t, s, 3)
+	dir := t.TempDir()
+	app := newTestApp(t, s, "payer1")
+	app.Config.Nodes["signer"] = NodeProfile{URL: s.URL, Role: RoleWallet}
+	app.Config.SendMany = sendManyTestConfig(addressFile)
+	app.Config.SendMany.MaxSendCount = 1
+	app.Config.SendMany.JournalFile = filepath.Join(dir, "journal.json")
+	app.Config.SendMany.PSBT = PSBTConfig{Dir: dir, Signer: "signer"}
+
+	if err := runSendMany(app); err != nil {
+		t.Fatal(err)
+	}
+	if mempool := s.Mempool(); len(mempool) != 1 {
+		t.Fatalf("mempool has %d transactions, want 1", len(mempool))
+	}
+	if n := s.Calls("walletprocesspsbt"); n != 1 {
+		t.Errorf("walletprocesspsbt called %d times, want 1", n)
+	}
+}
+
+func TestSendManyFeeModes(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	addressFile, _ := setupSendMany(t, s, 3)
+	app := newTestApp(t, s, "payer1")
+	app.Config.SendMany = sendManyTestConfig(addressFile)
+	app.Config.SendMany.MaxSendCount = 1
+
+	tests := []struct {
+		fee      SendFeeConfig
+		smartFee int64
+		want     float64
+	}{
+		// estimatesmartfee 低于下限时使用 minFeerate
+		{fee: SendFeeConfig{Mode: FeeModeEstimate, MinFeerate: 4}, smartFee: 3, want: 4},
+		// 节点钱包按 conf_target 估算
+		{fee: SendFeeConfig{Mode: FeeModeConfTarget, ConfTarget: 6}, smartFee: 7, want: 7},
+	}
+	for _, tt := range tests {
+		s.SetSmartFee(tt.smartFee)
+		app.Config.SendMany.Fee = tt.fee
+		if err := runSendMany(app); err != nil {
+			t.Fatal(err)
+		}
+		mempool := s.Mempool()
+		if len(mempool) != 1 {
+			t.Fatalf("mempool has %d transactions, want 1", len(mempool))
+		}
+		if tx, _ := s.Tx(mempool[0]); tx.FeeRate() != tt.want {
+			t.Errorf("%+v: fee rate = %v, want %v", tt.fee, tx.FeeRate(), tt.want)
+		}
+		s.Mine(1)
+	}
+
+	// 估算费率超过 maxFeerate 时这笔交易失败，不创建交易；试运行没有进展时结束
+	s.SetSmartFee(30)
+	app.Flags.DryRun = true
+	for _, fee := range []SendFeeConfig{{Mode: FeeModeEstimate, MaxFeerate: 20}, {Mode: FeeModeConfTarget, MaxFeerate: 20}} {
+		app.Config.SendMany.Fee = fee
+		if err := runSendMany(app); err != nil {
+			t.Fatal(err)
+		}
+		if n := s.Calls("walletcreatefundedpsbt"); n != 0 {
+			t.Errorf("%+v: walletcreatefundedpsbt called %d times, want 0", fee, n)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"address/rpc"
+)
+
+// 费率提升策略
+const (
+	StrategyLinear         = "linear"           // 每次提高 feeBumpAmount（默认）
+	StrategyMultiplicative = "multiplicative"   // 每次乘以 feeBumpFactor
+	StrategyEstimate       = "estimatesmartfee" // 提高到 estimatesmartfee 估算的费率
+	StrategyPercentile     = "percentile"       // 提高到当前交易池费率的第 percentile 百分位
+)
+
+// 策略参数的默认值
+const (
+	defaultFeeBumpFactor = 1.5
+	defaultConfTarget    = 2
+	defaultPercentile    = 50
+)
+
+// FeeStrategy 决定交易下一次提高费率的目标费率
+type FeeStrategy interface {
+	// NextFeerate 返回当前费率为 current（sat/vB）的交易的目标费率，不高于 current 表示不需要提高
+	NextFeerate(ctx context.Context, current float64) (float64, error)
+}
+
+// feeSource 是策略需要的节点RPC，*rpc.Client 实现了它，测试中可替换
+type feeSource interface {
+	EstimateSmartFee(ctx context.Context, confTarget int, estimateMode string) (*rpc.SmartFee, error)
+	GetRawMempoolVerbose(ctx context.Context) (map[string]rpc.MempoolEntry, error)
+}
+
+type linearStrategy struct {
+	amount float64
+}
+
+func (s linearStrategy) NextFeerate(_ context.Context, current float64) (float64, error) {
+	return current + s.amount, nil
+}
+
+// multiplicativeStrategy 按倍数提高，至少提高 1 sat/vB 以满足 bumpfee 的最小增量
+type multiplicativeStrategy struct {
+	factor float64
+}
+
+func (s multiplicativeStrategy) NextFeerate(_ context.Context, current float64) (float64, error) {
+	return math.Max(current*s.factor, current+1), nil
+}
+
+type estimateStrategy struct {
+	source     feeSource
+	confTarget int
+	mode       string
+}
+
+func (s estimateStrategy) NextFeerate(ctx context.Context, _ float64) (float64, error) {
+	fee, err := s.source.EstimateSmartFee(ctx, s.confTarget, s.mode)
+	if err != nil {
+		return 0, err
+	}
+	if fee.FeeRate <= 0 {
+		return 0, fmt.Errorf("estimatesmartfee %d: %s", s.confTarget, strings.Join(fee.Errors, "; "))
+	}
+	// BTCW/kvB 转换为 sat/vB
+	return fee.FeeRate * 1e5, nil
+}
+
+type percentileStrategy struct {
+	source     feeSource
+	percentile float64
+}
+
+func (s percentileStrategy) NextFeerate(ctx context.Context, _ float64) (float64, error) {
+	entries, err := s.source.GetRawMempoolVerbose(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, errors.New("mempool is empty")
+	}
+	rates := make([]float64, 0, len(entries))
+	for _, entry := range entries {
+		entry := entry
+		rates = append(rates, mempoolFeerates(&entry).Effective)
+	}
+	return percentile(rates, s.percentile), nil
+}
+
+// percentile 按最近秩法返回 rates 的第 p 百分位，会对 rates 排序
+func percentile(rates []float64, p float64) float64 {
+	sort.Float64s(rates)
+	i := int(math.Ceil(p/100*float64(len(rates)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(rates) {
+		i = len(rates) - 1
+	}
+	return rates[i]
+}
+
+func validStrategy(name string) bool {
+	return name == "" || name == StrategyLinear || name == StrategyMultiplicative || name == StrategyEstimate || name == StrategyPercentile
+}
+
+// validEstimateMode 返回 mode 是否是 estimatesmartfee 接受的估算模式，空表示节点默认值
+func validEstimateMode(mode string) bool {
+	switch strings.ToLower(mode) {
+	case "", "unset", "economical", "conservative":
+		return true
+	}
+	return false
+}
+
+// newFeeStrategy 按名称和 bumpfee 配置创建策略
+func newFeeStrategy(name string, c BumpFeeConfig, source feeSource) FeeStrategy {
+	switch name {
+	case StrategyMultiplicative:
+		factor := c.FeeBumpFactor
+		if factor == 0 {
+			factor = defaultFeeBumpFactor
+		}
+		return multiplicativeStrategy{factor: factor}
+	case StrategyEstimate:
+		confTarget := c.ConfTarget
+		if confTarget == 0 {
+			confTarget = defaultConfTarget
+		}
+		return estimateStrategy{source: source, confTarget: confTarget, mode: c.EstimateMode}
+	case StrategyPercentile:
+		p := c.Percentile
+		if p == 0 {
+			p = defaultPercentile
+		}
+		return percentileStrategy{source: source, percentile: p}
+	default:
+		return linearStrategy{amount: c.FeeBumpAmount}
+	}
+}
+
+// strategy 返回钱包使用的费率提升策略及其名称
+func (b *bumper) strategy(walletName string) (string, FeeStrategy) {
+	name := b.config.Strategy
+	if s, ok := b.config.WalletStrategies[walletName]; ok && s != "" {
+		name = s
+	}
+	if name == "" {
+		name = StrategyLinear
+	}
+	return name, newFeeStrategy(name, b.config, b.client)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"address/rpc"
+)
+
+// fakeFeeSource 在不连接节点的情况下为策略提供费率数据
+type fakeFeeSource struct {
+	smartFee *rpc.SmartFee
+	mempool  map[string]rpc.MempoolEntry
+	err      error
+}
+
+func (f fakeFeeSource) EstimateSmartFee(context.Context, int, string) (*rpc.SmartFee, error) {
+	return f.smartFee, f.err
+}
+
+func (f fakeFeeSource) GetRawMempoolVerbose(context.Context) (map[string]rpc.MempoolEntry, error) {
+	return f.mempool, f.err
+}
+
+// mempoolTx 返回费率为 rate（sat/vB）、没有祖先和后代的交易池条目
+func mempoolTx(rate float64) rpc.MempoolEntry {
+	fee := rate * 100 / 1e8
+	return rpc.MempoolEntry{
+		VSize: 100, AncestorSize: 100, DescendantSize: 100,
+		Fees: rpc.MempoolFees{Base: fee, Modified: fee, Ancestor: fee, Descendant: fee},
+	}
+}
+
+func TestFeeStrategies(t *testing.T) {
+	mempool := map[string]rpc.MempoolEntry{"a": mempoolTx(5), "b": mempoolTx(40), "c": mempoolTx(12), "d": mempoolTx(20)}
+	source := fakeFeeSource{smartFee: &rpc.SmartFee{FeeRate: 0.00031, Blocks: 2}, mempool: mempool}
+	tests := []struct {
+		name    string
+		config  BumpFeeConfig
+		current float64
+		want    float64
+	}{
+		{name: StrategyLinear, config: BumpFeeConfig{FeeBumpAmount: 10}, current: 10, want: 20},
+		{name: StrategyMultiplicative, config: BumpFeeConfig{FeeBumpFactor: 2}, current: 10, want: 20},
+		// 倍数提高不足 1 sat/vB 时至少提高 1 sat/vB
+		{name: StrategyMultiplicative, config: BumpFeeConfig{}, current: 1, want: 2},
+		{name: StrategyEstimate, config: BumpFeeConfig{ConfTarget: 2}, current: 10, want: 31},
+		{name: StrategyPercentile, config: BumpFeeConfig{}, current: 10, want: 12},
+		{name: StrategyPercentile, config: BumpFeeConfig{Percentile: 90}, current: 10, want: 40},
+	}
+	for _, tt := range tests {
+		got, err := newFeeStrategy(tt.name, tt.config, source).NextFeerate(context.Background(), tt.current)
+		if err != nil || got != tt.want {
+			t.Errorf("%s %+v: NextFeerate(%v) = %v, %v, want %v", tt.name, tt.config, tt.current, got, err, tt.want)
+		}
+	}
+}
+
+func TestFeeStrategyErrors(t *testing.T) {
+	ctx := context.Background()
+	noData := fakeFeeSource{smartFee: &rpc.SmartFee{Errors: []string{"Insufficient data or no feerate found"}}}
+	if _, err := newFeeStrategy(StrategyEstimate, BumpFeeConfig{}, noData).NextFeerate(ctx, 10); err == nil {
+		t.Error("want error when estimatesmartfee has no data")
+	}
+	if _, err := newFeeStrategy(StrategyPercentile, BumpFeeConfig{}, fakeFeeSource{}).NextFeerate(ctx, 10); err == nil {
+		t.Error("want error for an empty mempool")
+	}
+	failing := fakeFeeSource{err: errors.New("connection refused")}
+	if _, err := newFeeStrategy(StrategyPercentile, BumpFeeConfig{}, failing).NextFeerate(ctx, 10); err == nil {
+		t.Error("want RPC error")
+	}
+}
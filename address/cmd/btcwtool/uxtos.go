@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sync"
+
+	"address/rpc"
+
+	"go.uber.org/zap"
+)
+
+// UxtosConfig uxtos 子命令的配置
+type UxtosConfig struct {
+	Node string `yaml:"node"`
+	// Wallets 选择查询的钱包
+	Wallets WalletFilter `yaml:"wallets"`
+	Minconf int          `yaml:"minconf"`
+	// Concurrency 同时查询的钱包数，默认 4
+	Concurrency int `yaml:"concurrency"`
+}
+
+// uxtosCommand 用于列出wallets，balance，uxtos数量
+var uxtosCommand = &command{
+	name:  "uxtos",
+	usage: "list utxos count and balances for every wallet",
+	flags: func(fs *flag.FlagSet, config *Config) {
+		fs.IntVar(&config.Uxtos.Minconf, "minconf", config.Uxtos.Minconf, "minimum confirmations, 0 includes unconfirmed outputs")
+		config.Uxtos.Wallets.register(fs)
+		fs.IntVar(&config.Uxtos.Concurrency, "concurrency", config.Uxtos.Concurrency, "number of wallets queried concurrently")
+	},
+	run: runUxtos,
+}
+
+func runUxtos(app *App) error {
+	format := "%-40s %v"
+	config := app.Config.Uxtos
+	sugar := app.Sugar
+	ctx := app.Ctx
+
+	node, err := app.Node(config.Node, RoleWallet)
+	if err != nil {
+		return err
+	}
+	client := node.Client
+	sugar.Infof("")
+	sugar.Infof(format, "Starting uxtos, RPC server:", client.URL())
+
+	// 调用 listwallets RPC
+	wallets, err := node.Wallets(ctx, config.Wallets)
+	if err != nil {
+		return fmt.Errorf("error listing wallets: %w", err)
+	}
+	sugar.Infof("Node load wallet(s):%s", wallets)
+
+	// 各钱包并发查询，结果按钱包顺序输出
+	type walletUtxos struct {
+		balances *rpc.Balances
+		count    int
+	}
+	results := make(map[string]walletUtxos, len(wallets))
+	var mu sync.Mutex
+	sugar.Infof("minconf: %v", config.Minconf)
+	err = forEachWallet(ctx, sugar, wallets, config.Concurrency, func(ctx context.Context, walletName string, sugar *zap.SugaredLogger) error {
+		sugar.Infof("Processing wallet: %s", walletName)
+		walletClient := client.Wallet(walletName)
+		// 调用 getbalances RPC
+		balances, err := walletClient.GetBalances(ctx)
+		if err != nil {
+			return fmt.Errorf("error getting balance: %w", err)
+		}
+		// 调用 listunspent RPC
+		unspentOutputs, err := walletClient.ListUnspent(ctx, config.Minconf, 9999999, nil, true, nil)
+		if err != nil {
+			return fmt.Errorf("error listing unspent outputs: %w", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		results[walletName] = walletUtxos{balances: balances, count: len(unspentOutputs)}
+		return nil
+	})
+
+	totalbalance := 0.0
+	for _, walletName := range wallets {
+		r, ok := results[walletName]
+		if !ok {
+			continue
+		}
+		sugar.Infof("Wallet: %s", walletName)
+		sugar.Infof("Balances: %+v", *r.balances)
+		sugar.Infof("Number of Unspent Outputs: %v", r.count)
+		totalbalance += r.balances.Mine.Trusted
+	}
+	sugar.Infof("The total balance is: %f", totalbalance)
+	return err
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// WalletEditConfig walletedit 子命令的配置
+type WalletEditConfig struct {
+	InputFilePath  string `yaml:"inputFilePath"`
+	OutputFilePath string `yaml:"outputFilePath"`
+}
+
+// walletEditCommand 处理 dumpwallet 导出的文件，只保留包含 "label" 的行
+var walletEditCommand = &command{
+	name:  "walletedit",
+	usage: "process readable dumpwallet and reserve \"label\" lines",
+	flags: func(fs *flag.FlagSet, config *Config) {
+		c := &config.WalletEdit
+		fs.StringVar(&c.InputFilePath, "input", c.InputFilePath, "dumpwallet file")
+		fs.StringVar(&c.OutputFilePath, "output", c.OutputFilePath, "output file")
+	},
+	run: runWalletEdit,
+}
+
+func runWalletEdit(app *App) error {
+	config := app.Config.WalletEdit
+
+	inputFile, err := os.Open(config.InputFilePath)
+	if err != nil {
+		return fmt.Errorf("error opening input file: %w", err)
+	}
+	defer inputFile.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(inputFile)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, "label") {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading input file: %w", err)
+	}
+	app.Sugar.Infof("Found %d label lines in %s", len(lines), config.InputFilePath)
+	if app.Flags.DryRun {
+		return nil
+	}
+
+	outputFile, err := os.Create(config.OutputFilePath)
+	if err != nil {
+		return fmt.Errorf("error creating output file: %w", err)
+	}
+	defer outputFile.Close()
+
+	writer := bufio.NewWriter(outputFile)
+	for _, line := range lines {
+		if _, err := writer.WriteString(line + "\n"); err != nil {
+			return fmt.Errorf("error writing to output file: %w", err)
+		}
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// WalletFilter 按名称选择多钱包命令处理的钱包，名称支持 path.Match 通配符（如 btcw*）
+type WalletFilter struct {
+	// Include 只处理匹配的钱包，为空时处理全部钱包
+	Include []string `yaml:"include"`
+	// Exclude 跳过匹配的钱包，优先于 Include
+	Exclude []string `yaml:"exclude"`
+}
+
+// Match 返回钱包 name 是否被选中
+func (f WalletFilter) Match(name string) bool {
+	if len(f.Include) > 0 && !matchAny(f.Include, name) {
+		return false
+	}
+	return !matchAny(f.Exclude, name)
+}
+
+// validate 检查通配符语法
+func (f WalletFilter) validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad wallet pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// register 注册 -include-wallets 和 -exclude-wallets 参数，覆盖配置中的列表
+func (f *WalletFilter) register(fs *flag.FlagSet) {
+	fs.Var((*walletList)(&f.Include), "include-wallets", "comma-separated wallet names or patterns to process, e.g. btcw*")
+	fs.Var((*walletList)(&f.Exclude), "exclude-wallets", "comma-separated wallet names or patterns to skip")
+}
+
+// matchAny 返回 name 是否匹配 patterns 中的任一名称或通配符
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// walletList 是逗号分隔的钱包列表参数
+type walletList []string
+
+func (l *walletList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *walletList) Set(s string) error {
+	*l = nil
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			*l = append(*l, name)
+		}
+	}
+	return nil
+}
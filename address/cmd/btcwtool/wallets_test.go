@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"reflect"
+	"testing"
+
+	"address/rpc/rpctest"
+)
+
+func TestWalletFilter(t *testing.T) {
+	filter := WalletFilter{Include: []string{"btcw*", "payer"}, Exclude: []string{"btcw1?", "btcw-cold"}}
+	for name, want := range map[string]bool{
+		"btcw1":     true,
+		"btcw17":    false,
+		"btcw-cold": false,
+		"payer":     true,
+		"miner":     false,
+	} {
+		if got := filter.Match(name); got != want {
+			t.Errorf("Match(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if !(WalletFilter{}).Match("anything") {
+		t.Error("empty filter must select every wallet")
+	}
+	if err := (WalletFilter{Exclude: []string{"btcw["}}).validate(); err == nil {
+		t.Error("want error for bad pattern")
+	}
+}
+
+func TestWalletFilterFlags(t *testing.T) {
+	filter := WalletFilter{Include: []string{"from-config"}}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	filter.register(fs)
+	if err := fs.Parse([]string{"-include-wallets", "btcw*, payer", "-exclude-wallets=miner"}); err != nil {
+		t.Fatal(err)
+	}
+	want := WalletFilter{Include: []string{"btcw*", "payer"}, Exclude: []string{"miner"}}
+	if !reflect.DeepEqual(filter, want) {
+		t.Fatalf("got %+v, want %+v", filter, want)
+	}
+}
+
+func TestNodeWallets(t *testing.T) {
+	s := rpctest.NewServer()
+	defer s.Close()
+	for _, name := range []string{"btcw1", "btcw2", "btcw-cold", "miner"} {
+		s.CreateWallet(name)
+	}
+	// 节点白名单支持通配符，命令的 filter 在白名单内进一步筛选
+	app := newTestApp(t, s, "btcw*")
+	node, err := app.Node("", RoleWallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallets, err := node.Wallets(context.Background(), WalletFilter{Exclude: []string{"*cold"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(wallets, []string{"btcw1", "btcw2"}) {
+		t.Fatalf("wallets %v, want [btcw1 btcw2]", wallets)
+	}
+}
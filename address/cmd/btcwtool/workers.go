@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// defaultConcurrency 是未配置 concurrency 时同时处理的钱包数
+const defaultConcurrency = 4
+
+// walletFunc 处理一个钱包，sugar 的每条日志都带有 wallet 字段
+type walletFunc func(ctx context.Context, walletName string, sugar *zap.SugaredLogger) error
+
+// forEachWallet 最多同时对 concurrency 个钱包调用 fn，concurrency 小于 1 时使用 defaultConcurrency。
+// 一个钱包出错或 panic 不影响其它钱包，返回按钱包顺序合并的错误
+func forEachWallet(ctx context.Context, sugar *zap.SugaredLogger, wallets []string, concurrency int, fn walletFunc) error {
+	if concurrency < 1 {
+		concurrency = defaultConcurrency
+	}
+	errs := make([]error, len(wallets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, walletName := range wallets {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, walletName string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer func() {
+				if r := recover(); r != nil {
+					errs[i] = fmt.Errorf("wallet %s: panic: %v", walletName, r)
+				}
+			}()
+			if err := fn(ctx, walletName, sugar.With("wallet", walletName)); err != nil {
+				errs[i] = fmt.Errorf("wallet %s: %w", walletName, err)
+			}
+		}(i, walletName)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
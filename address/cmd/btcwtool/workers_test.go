@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestForEachWallet(t *testing.T) {
+	wallets := []string{"w1", "w2", "w3", "w4", "w5", "w6"}
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	done := make(map[string]bool)
+	err := forEachWallet(context.Background(), zap.NewNop().Sugar(), wallets, 2, func(_ context.Context, walletName string, _ *zap.SugaredLogger) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		done[walletName] = true
+		mu.Unlock()
+
+		switch walletName {
+		case "w2":
+			return errors.New("connection refused")
+		case "w5":
+			panic("boom")
+		}
+		return nil
+	})
+	if maxRunning != 2 {
+		t.Errorf("ran %d wallets at once, want 2", maxRunning)
+	}
+	// 出错和 panic 的钱包不影响其它钱包
+	if len(done) != len(wallets) {
+		t.Errorf("processed %v, want all wallets", done)
+	}
+	if err == nil || !strings.Contains(err.Error(), "wallet w2: connection refused") || !strings.Contains(err.Error(), "wallet w5: panic: boom") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
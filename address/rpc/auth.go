@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 覆盖 config.yaml 中 username/password 的环境变量
+const (
+	EnvUsername = "BTCW_RPC_USER"
+	EnvPassword = "BTCW_RPC_PASSWORD"
+)
+
+// ErrNoCredentials 表示配置中没有任何可用的认证方式
+var ErrNoCredentials = errors.New("no RPC credentials configured (set cookieFile, datadir, credentialsFile, username/password or " + EnvUsername + "/" + EnvPassword + ")")
+
+// Credentials 返回 Basic 认证所需的用户名和密码
+type Credentials func() (username, password string, err error)
+
+// Auth 是各命令 config.yaml 中共用的认证配置，按以下顺序选用：
+// cookieFile、datadir 下的 .cookie、credentialsFile、环境变量、username/password
+type Auth struct {
+	Username        string `yaml:"username"`
+	Password        string `yaml:"password"`
+	CookieFile      string `yaml:"cookieFile"`
+	Datadir         string `yaml:"datadir"`
+	CredentialsFile string `yaml:"credentialsFile"`
+}
+
+// Option 把认证配置转换为 NewClient 的选项
+func (a Auth) Option() (Option, error) {
+	switch {
+	case a.CookieFile != "":
+		return WithCookieFile(a.CookieFile), nil
+	case a.Datadir != "":
+		return WithCookieFile(filepath.Join(a.Datadir, ".cookie")), nil
+	case a.CredentialsFile != "":
+		username, password, err := readUserPass(a.CredentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		return WithBasicAuth(username, password), nil
+	}
+
+	username, password := a.Username, a.Password
+	if v, ok := os.LookupEnv(EnvUsername); ok {
+		username = v
+	}
+	if v, ok := os.LookupEnv(EnvPassword); ok {
+		password = v
+	}
+	if username == "" && password == "" {
+		return nil, ErrNoCredentials
+	}
+	return WithBasicAuth(username, password), nil
+}
+
+// WithCookieFile 使用节点生成的 .cookie 文件认证，每次请求重新读取，节点重启后无需重启工具
+func WithCookieFile(path string) Option {
+	return WithCredentials(func() (string, string, error) {
+		return readUserPass(path)
+	})
+}
+
+// readUserPass 读取 "user:password" 格式的文件，.cookie 和凭据文件都使用这一格式
+func readUserPass(path string) (string, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("reading credentials: %w", err)
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	username, password, ok := strings.Cut(strings.TrimSpace(line), ":")
+	if !ok {
+		return "", "", fmt.Errorf("reading credentials: %s is not in user:password format", path)
+	}
+	return username, password, nil
+}
@@ -0,0 +1,77 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCookieFileReadPerRequest(t *testing.T) {
+	var gotUser, gotPass string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, gotPass, _ = r.BasicAuth()
+		w.Write([]byte(`{"result":1,"error":null,"id":1}`))
+	}))
+	defer srv.Close()
+
+	datadir := t.TempDir()
+	cookie := filepath.Join(datadir, ".cookie")
+	if err := os.WriteFile(cookie, []byte("__cookie__:first"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	opt, err := Auth{Datadir: datadir, Username: "ignored"}.Option()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(srv.URL, opt)
+
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if gotUser != "__cookie__" || gotPass != "first" {
+		t.Errorf("auth = %s:%s", gotUser, gotPass)
+	}
+
+	// 节点重启后 .cookie 会重新生成
+	if err := os.WriteFile(cookie, []byte("__cookie__:second\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if gotPass != "second" {
+		t.Errorf("cookie not re-read, password = %s", gotPass)
+	}
+}
+
+func TestAuthOptionEnvOverride(t *testing.T) {
+	t.Setenv(EnvUsername, "envuser")
+	t.Setenv(EnvPassword, "envpass")
+
+	var gotUser, gotPass string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, gotPass, _ = r.BasicAuth()
+		w.Write([]byte(`{"result":[],"error":null,"id":1}`))
+	}))
+	defer srv.Close()
+
+	opt, err := Auth{Username: "USER", Password: "PASS"}.Option()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewClient(srv.URL, opt).ListWallets(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if gotUser != "envuser" || gotPass != "envpass" {
+		t.Errorf("auth = %s:%s", gotUser, gotPass)
+	}
+}
+
+func TestAuthOptionMissing(t *testing.T) {
+	if _, err := (Auth{}).Option(); err != ErrNoCredentials {
+		t.Errorf("err = %v, want ErrNoCredentials", err)
+	}
+}
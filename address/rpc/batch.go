@@ -0,0 +1,120 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// DefaultBatchSize 是各命令每次 HTTP 请求打包的默认调用数
+const DefaultBatchSize = 500
+
+// ErrNoResponse 表示批量响应中缺少某个调用的结果
+var ErrNoResponse = errors.New("no response for batch call")
+
+// BatchCall 是批量请求中的一个调用，Send 之后 Err 保存该调用自己的错误
+type BatchCall struct {
+	Method string
+	Params []interface{}
+	Result interface{}
+	Err    error
+
+	id uint64
+}
+
+// Batch 收集多个调用，通过一次 HTTP 请求（JSON 数组）发送给节点
+type Batch struct {
+	client *Client
+	calls  []*BatchCall
+}
+
+// NewBatch 创建发往本客户端地址的批量请求，钱包RPC请使用 Wallet(name).NewBatch()
+func (c *Client) NewBatch() *Batch {
+	return &Batch{client: c}
+}
+
+// Add 追加一个调用，result 为 nil 时丢弃结果
+func (b *Batch) Add(method string, result interface{}, params ...interface{}) *BatchCall {
+	if params == nil {
+		params = []interface{}{}
+	}
+	call := &BatchCall{
+		Method: method,
+		Params: params,
+		Result: result,
+		id:     atomic.AddUint64(b.client.nextID, 1),
+	}
+	b.calls = append(b.calls, call)
+	return call
+}
+
+// Calls 返回已添加的调用
+func (b *Batch) Calls() []*BatchCall {
+	return b.calls
+}
+
+// Len 返回已添加的调用数
+func (b *Batch) Len() int {
+	return len(b.calls)
+}
+
+// Send 发送所有调用。返回的错误仅表示整个请求失败（网络、认证等），
+// 单个调用的 RPC 错误保存在各自的 BatchCall.Err 中
+func (b *Batch) Send(ctx context.Context) error {
+	if len(b.calls) == 0 {
+		return nil
+	}
+	reqs := make([]Request, len(b.calls))
+	methods := make([]string, len(b.calls))
+	for i, call := range b.calls {
+		methods[i] = call.Method
+		reqs[i] = Request{
+			Jsonrpc: "1.0",
+			ID:      call.id,
+			Method:  call.Method,
+			Params:  call.Params,
+		}
+	}
+
+	// 单个调用的 RPC 错误不重试，只重试整个请求的网络错误
+	var responses []Response
+	err := b.client.do(ctx, methods, func(c *Client) error {
+		responses = nil
+		return c.post(ctx, reqs, &responses)
+	})
+	if err != nil {
+		return fmt.Errorf("batch of %d calls: %w", len(reqs), err)
+	}
+
+	byID := make(map[uint64]*Response, len(responses))
+	for i := range responses {
+		byID[responses[i].ID] = &responses[i]
+	}
+	for _, call := range b.calls {
+		resp, ok := byID[call.id]
+		switch {
+		case !ok:
+			call.Err = fmt.Errorf("%s: %w", call.Method, ErrNoResponse)
+		case resp.Error != nil:
+			call.Err = fmt.Errorf("%s: %w", call.Method, resp.Error)
+		case call.Result != nil:
+			if err := json.Unmarshal(resp.Result, call.Result); err != nil {
+				call.Err = fmt.Errorf("%s: decoding result: %w", call.Method, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Errors 返回 Send 之后失败的调用数
+func (b *Batch) Errors() int {
+	n := 0
+	for _, call := range b.calls {
+		if call.Err != nil {
+			n++
+		}
+	}
+	return n
+}
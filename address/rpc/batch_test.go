@@ -0,0 +1,53 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBatchPerItemErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqs []Request
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			t.Fatalf("decoding batch: %v", err)
+		}
+		// 倒序返回，并让第二个调用失败、最后一个调用缺失
+		var resps []map[string]interface{}
+		for i := len(reqs) - 2; i >= 0; i-- {
+			resp := map[string]interface{}{"id": reqs[i].ID, "error": nil, "result": "addr" + string(rune('0'+i))}
+			if i == 1 {
+				resp["result"] = nil
+				resp["error"] = map[string]interface{}{"code": ErrCodeWallet, "message": "Error: This wallet has no available keys"}
+			}
+			resps = append(resps, resp)
+		}
+		json.NewEncoder(w).Encode(resps)
+	}))
+	defer srv.Close()
+
+	batch := NewClient(srv.URL).Wallet("btcw17").NewBatch()
+	addrs := make([]string, 4)
+	for i := range addrs {
+		batch.Add("getnewaddress", &addrs[i], "", "legacy")
+	}
+	if err := batch.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := batch.Calls()
+	if calls[0].Err != nil || addrs[0] != "addr0" || calls[2].Err != nil || addrs[2] != "addr2" {
+		t.Errorf("successful calls: %v %q, %v %q", calls[0].Err, addrs[0], calls[2].Err, addrs[2])
+	}
+	if !IsCode(calls[1].Err, ErrCodeWallet) {
+		t.Errorf("calls[1].Err = %v", calls[1].Err)
+	}
+	if calls[3].Err == nil {
+		t.Errorf("missing response should be reported")
+	}
+	if batch.Errors() != 2 {
+		t.Errorf("Errors() = %d, want 2", batch.Errors())
+	}
+}
@@ -0,0 +1,76 @@
+package rpc
+
+import "context"
+
+// BlockHeader 是 getblockheader verbose=true 的结果
+type BlockHeader struct {
+	Hash              string  `json:"hash"`
+	Confirmations     int64   `json:"confirmations"`
+	Height            int64   `json:"height"`
+	Version           int32   `json:"version"`
+	VersionHex        string  `json:"versionHex"`
+	MerkleRoot        string  `json:"merkleroot"`
+	Time              int64   `json:"time"`
+	MedianTime        int64   `json:"mediantime"`
+	Nonce             uint32  `json:"nonce"`
+	Bits              string  `json:"bits"`
+	Difficulty        float64 `json:"difficulty"`
+	Chainwork         string  `json:"chainwork"`
+	NTx               int     `json:"nTx"`
+	PreviousBlockHash string  `json:"previousblockhash"`
+	NextBlockHash     string  `json:"nextblockhash"`
+}
+
+// ValidateAddressResult 是 validateaddress 的结果，地址无效时 Error 说明原因
+type ValidateAddressResult struct {
+	IsValid      bool   `json:"isvalid"`
+	Address      string `json:"address"`
+	ScriptPubKey string `json:"scriptPubKey"`
+	IsScript     bool   `json:"isscript"`
+	IsWitness    bool   `json:"iswitness"`
+	Error        string `json:"error"`
+}
+
+// GetBlockCount 返回当前区块高度
+func (c *Client) GetBlockCount(ctx context.Context) (int64, error) {
+	var count int64
+	err := c.Call(ctx, "getblockcount", &count)
+	return count, err
+}
+
+// GetBlockHash 返回指定高度的区块哈希
+func (c *Client) GetBlockHash(ctx context.Context, height int64) (string, error) {
+	var hash string
+	err := c.Call(ctx, "getblockhash", &hash, height)
+	return hash, err
+}
+
+// GetBlockHeader 返回区块头信息
+func (c *Client) GetBlockHeader(ctx context.Context, hash string) (*BlockHeader, error) {
+	var header BlockHeader
+	if err := c.Call(ctx, "getblockheader", &header, hash, true); err != nil {
+		return nil, err
+	}
+	return &header, nil
+}
+
+// GetNetworkHashPS 返回截至 height 前 nblocks 个区块的平均全网算力
+func (c *Client) GetNetworkHashPS(ctx context.Context, nblocks int, height int64) (float64, error) {
+	var hashps float64
+	err := c.Call(ctx, "getnetworkhashps", &hashps, nblocks, height)
+	return hashps, err
+}
+
+// PrioritiseTransaction 在本节点的挖矿模板中调整交易的优先级
+func (c *Client) PrioritiseTransaction(ctx context.Context, txid string, feeDelta float64) error {
+	return c.Call(ctx, "prioritisetransaction", nil, txid, 0, feeDelta)
+}
+
+// ValidateAddress 检查地址是否是本网络的有效地址
+func (c *Client) ValidateAddress(ctx context.Context, address string) (*ValidateAddressResult, error) {
+	var result ValidateAddressResult
+	if err := c.Call(ctx, "validateaddress", &result, address); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
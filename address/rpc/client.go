@@ -0,0 +1,175 @@
+// Package rpc 是各命令共用的 BitcoinPoW 节点 JSON-RPC 客户端
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout 单次 HTTP 请求的默认超时，sendmany 大交易签名较慢，留足余量
+const DefaultTimeout = 120 * time.Second
+
+// Request 和 Response 分别定义了RPC请求和响应的结构
+type Request struct {
+	Jsonrpc string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type Response struct {
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+	ID     uint64          `json:"id"`
+}
+
+// Option 用于 NewClient 的可选配置
+type Option func(*Client)
+
+// WithBasicAuth 使用 rpcuser/rpcpassword 或 rpcauth 中配置的用户名密码认证
+func WithBasicAuth(username, password string) Option {
+	return WithCredentials(func() (string, string, error) {
+		return username, password, nil
+	})
+}
+
+// WithCredentials 在每次请求时调用 credentials 获取用户名和密码
+func WithCredentials(credentials Credentials) Option {
+	return func(c *Client) {
+		c.credentials = credentials
+	}
+}
+
+// WithTimeout 设置单次 HTTP 请求超时
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.httpClient.Timeout = timeout
+	}
+}
+
+// WithHTTPClient 替换底层 http.Client，多个 Client 可共享同一个连接池
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// Client 持有节点地址、认证信息和共享的 http.Client
+type Client struct {
+	url         string
+	credentials Credentials
+	httpClient  *http.Client
+	nextID      *uint64
+	retry       RetryPolicy
+	failover    *Client
+}
+
+// NewClient 创建指向节点根地址（如 http://192.168.8.115:9330）的客户端
+func NewClient(nodeURL string, opts ...Option) *Client {
+	c := &Client{
+		url:        strings.TrimRight(nodeURL, "/"),
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		nextID:     new(uint64),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// URL 返回客户端请求的完整地址
+func (c *Client) URL() string {
+	return c.url
+}
+
+// Wallet 返回访问指定钱包（/wallet/<name>）的客户端，与原客户端共享连接、认证和重试策略
+func (c *Client) Wallet(name string) *Client {
+	w := *c
+	w.url = c.url + "/wallet/" + url.PathEscape(name)
+	w.failover = nil
+	return &w
+}
+
+// Call 调用 method，并把 result 字段解码到 result 中；result 为 nil 时丢弃结果
+func (c *Client) Call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	reqBody := Request{
+		Jsonrpc: "1.0",
+		ID:      atomic.AddUint64(c.nextID, 1),
+		Method:  method,
+		Params:  params,
+	}
+
+	var response Response
+	err := c.do(ctx, []string{method}, func(c *Client) error {
+		response = Response{}
+		if err := c.post(ctx, reqBody, &response); err != nil {
+			return err
+		}
+		if response.Error != nil {
+			return response.Error
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("%s: decoding result: %w", method, err)
+	}
+	return nil
+}
+
+// post 发送一次 HTTP POST 并把响应体解码到 out
+func (c *Client) post(ctx context.Context, payload interface{}, out interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(jsonData))
+	if err != nil {
+		return err
+	}
+	if c.credentials != nil {
+		username, password, err := c.credentials()
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(username, password)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// bitcoind 在RPC出错时返回 404/500 并附带 JSON 错误体，401/403 等则没有响应体
+	if err := json.Unmarshal(body, out); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return &HTTPError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+		}
+		return err
+	}
+	return nil
+}
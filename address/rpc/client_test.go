@@ -0,0 +1,386 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordedClient 返回一个客户端，其请求由 testdata/<name>.json 中录制的节点响应回答
+func recordedClient(t *testing.T, name string, wantMethod string) *Client {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		if req.Method != wantMethod {
+			t.Errorf("method = %q, want %q", req.Method, wantMethod)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "USER" || pass != "PASS" {
+			t.Errorf("missing basic auth")
+		}
+		var resp Response
+		if err := json.Unmarshal(body, &resp); err == nil && resp.Error != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL, WithBasicAuth("USER", "PASS"))
+}
+
+func TestListWallets(t *testing.T) {
+	wallets, err := recordedClient(t, "listwallets", "listwallets").ListWallets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wallets) != 3 || wallets[2] != "btcw17" {
+		t.Errorf("wallets = %v", wallets)
+	}
+}
+
+func TestListUnspent(t *testing.T) {
+	c := recordedClient(t, "listunspent", "listunspent")
+	unspent, err := c.ListUnspent(context.Background(), 0, 0, nil, true, &ListUnspentOptions{MinimumAmount: 0.00002})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unspent) != 1 {
+		t.Fatalf("len(unspent) = %d", len(unspent))
+	}
+	u := unspent[0]
+	if u.Vout != 1 || u.Amount != 0.027912 || u.Confirmations != 0 || u.AncestorCount != 3 || !u.Safe {
+		t.Errorf("unexpected unspent %+v", u)
+	}
+}
+
+func TestGetBalances(t *testing.T) {
+	balances, err := recordedClient(t, "getbalances", "getbalances").GetBalances(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances.Mine.Trusted != 1523.364584 || balances.Mine.UntrustedPending != 0.027912 {
+		t.Errorf("unexpected balances %+v", balances.Mine)
+	}
+	if balances.WatchOnly != nil {
+		t.Errorf("watchonly should be absent")
+	}
+}
+
+func TestGetTransaction(t *testing.T) {
+	tx, err := recordedClient(t, "gettransaction", "gettransaction").GetTransaction(context.Background(), "5b4f3c1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Fee != -0.000335 || tx.BIP125Replaceable != "yes" || len(tx.Details) != 2 || tx.Details[0].Category != "send" {
+		t.Errorf("unexpected transaction %+v", tx)
+	}
+	if len(tx.Hex) != 448 {
+		t.Errorf("len(hex) = %d", len(tx.Hex))
+	}
+}
+
+func TestListTransactions(t *testing.T) {
+	txs, err := recordedClient(t, "listtransactions", "listtransactions").ListTransactions(context.Background(), "*", 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("len(txs) = %d", len(txs))
+	}
+	if txs[0].Category != "receive" || txs[0].Label != "alice" || txs[0].Confirmations != 12 {
+		t.Errorf("unexpected receive %+v", txs[0])
+	}
+	send := txs[1]
+	if send.Category != "send" || send.Amount != -0.25 || send.Fee != -0.0000705 || send.Comment != "btcwtool sendmany 3f9a1c2e #4" || send.ReplacedByTxID != "2e7d9a0f" {
+		t.Errorf("unexpected send %+v", send)
+	}
+}
+
+func TestListSinceBlock(t *testing.T) {
+	result, err := recordedClient(t, "listsinceblock", "listsinceblock").ListSinceBlock(context.Background(), "00000000000000a4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Transactions) != 2 || len(result.Removed) != 0 || result.LastBlock == "" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if send := result.Transactions[0]; send.Category != "send" || send.Comment != "btcwtool sendmany 3f9a1c2e #4" || send.Confirmations != 0 {
+		t.Errorf("unexpected send %+v", send)
+	}
+}
+
+func TestLockUnspent(t *testing.T) {
+	ctx := context.Background()
+	outputs := []TxInput{{TxID: "5b4f3c1d2c0b5ad6b0e2f1a9f0e6d59c8c2a1b0f9e8d7c6b5a493827160f1e2d", Vout: 1}}
+	if err := recordedClient(t, "lockunspent", "lockunspent").LockUnspent(ctx, false, outputs); err != nil {
+		t.Fatal(err)
+	}
+	locked, err := recordedClient(t, "listlockunspent", "listlockunspent").ListLockUnspent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(locked, outputs) {
+		t.Errorf("ListLockUnspent = %+v, want %+v", locked, outputs)
+	}
+}
+
+func TestSendMany(t *testing.T) {
+	c := recordedClient(t, "sendmany", "sendmany")
+	result, err := c.SendMany(context.Background(), map[string]float64{"1KFHE7w8BhaENAswwryaoccDb6qcT6DbYY": 0.00001}, SendManyOptions{Minconf: 1, FeeRate: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TxID != "c6a0a3c9f4b5e2d1c0b9a8f7e6d5c4b3a2918f7e6d5c4b3a2918f7e6d5c4b3a2" || result.FeeReason != "Fallback fee" {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestBumpFee(t *testing.T) {
+	result, err := recordedClient(t, "bumpfee", "bumpfee").BumpFee(context.Background(), "5b4f3c1d", &BumpFeeOptions{FeeRate: 110})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OrigFee != 0.000335 || result.Fee != 0.00067 || len(result.Errors) != 0 {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestChainRPCs(t *testing.T) {
+	ctx := context.Background()
+
+	header, err := recordedClient(t, "getblockheader", "getblockheader").GetBlockHeader(ctx, "00000000000000b6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Height != 205117 || header.Bits != "1c2a1115" || header.Time != 1733975843 || header.NextBlockHash != "" {
+		t.Errorf("unexpected header %+v", header)
+	}
+
+	count, err := recordedClient(t, "getblockcount", "getblockcount").GetBlockCount(ctx)
+	if err != nil || count != 205117 {
+		t.Errorf("GetBlockCount = %d, %v", count, err)
+	}
+
+	hashps, err := recordedClient(t, "getnetworkhashps", "getnetworkhashps").GetNetworkHashPS(ctx, 10, 205117)
+	if err != nil || hashps != 2160845.212312 {
+		t.Errorf("GetNetworkHashPS = %f, %v", hashps, err)
+	}
+
+	valid, err := recordedClient(t, "validateaddress", "validateaddress").ValidateAddress(ctx, "bpw1qw508d6qejxtdg4y5r3zarvary0c5xw7kcwq5q3")
+	if err != nil || !valid.IsValid || !valid.IsWitness || valid.ScriptPubKey != "0014751e76e8199196d454941c45d1b3a323f1433bd6" {
+		t.Errorf("ValidateAddress = %+v, %v", valid, err)
+	}
+	invalid, err := recordedClient(t, "validateaddress_invalid", "validateaddress").ValidateAddress(ctx, "bpw1qinvalid")
+	if err != nil || invalid.IsValid || invalid.Error != "Invalid Bech32 checksum" {
+		t.Errorf("ValidateAddress = %+v, %v", invalid, err)
+	}
+}
+
+func TestListReceivedByAddress(t *testing.T) {
+	received, err := recordedClient(t, "listreceivedbyaddress", "listreceivedbyaddress").ListReceivedByAddress(context.Background(), 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 2 || len(received[0].Txids) != 2 || received[1].Txids == nil {
+		t.Errorf("unexpected result %+v", received)
+	}
+}
+
+func TestCreateWallet(t *testing.T) {
+	result, err := recordedClient(t, "createwallet", "createwallet").CreateWallet(context.Background(), "btcw17", CreateWalletOptions{LoadOnStartup: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Name != "btcw17" {
+		t.Errorf("name = %q", result.Name)
+	}
+}
+
+func TestRPCErrorKeepsCode(t *testing.T) {
+	c := recordedClient(t, "error_wallet_not_found", "getbalances").Wallet("missing")
+	_, err := c.GetBalances(context.Background())
+	if !IsCode(err, ErrCodeWalletNotFound) {
+		t.Fatalf("err = %v, want code %d", err, ErrCodeWalletNotFound)
+	}
+}
+
+func TestGetRawMempool(t *testing.T) {
+	txids, err := recordedClient(t, "getrawmempool", "getrawmempool").GetRawMempool(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txids) != 2 || txids[1][:8] != "5b4f3c1d" {
+		t.Errorf("txids = %v", txids)
+	}
+}
+
+func TestGetMempoolEntry(t *testing.T) {
+	entry, err := recordedClient(t, "getmempoolentry", "getmempoolentry").GetMempoolEntry(context.Background(), "5b4f3c1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.VSize != 141 || entry.AncestorCount != 3 || entry.AncestorSize != 423 || entry.Fees.Ancestor != 0.000282 || !entry.BIP125Replaceable {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
+
+func TestGetMempoolAncestors(t *testing.T) {
+	ancestors, err := recordedClient(t, "getmempoolancestors", "getmempoolancestors").GetMempoolAncestors(context.Background(), "5b4f3c1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, ok := ancestors["9d1e6f0b8c2a4e7f3b5d1c9a8e7f6d5c4b3a29180f7e6d5c4b3a291807f6e5d4"]
+	if len(ancestors) != 2 || !ok || parent.VSize != 141 || parent.DescendantCount != 2 || parent.AncestorCount != 2 {
+		t.Errorf("unexpected ancestors %+v", ancestors)
+	}
+}
+
+func TestGetRawMempoolVerbose(t *testing.T) {
+	entries, err := recordedClient(t, "getrawmempool_verbose", "getrawmempool").GetRawMempoolVerbose(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := entries["9d1e6f0b8c2a4e7f3b5d1c9a8e7f6d5c4b3a29180f7e6d5c4b3a291807f6e5d4"]
+	if len(entries) != 2 || !ok || entry.VSize != 226 || entry.Fees.Base != 0.0000452 || entry.BIP125Replaceable {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+}
+
+func TestEstimateSmartFee(t *testing.T) {
+	fee, err := recordedClient(t, "estimatesmartfee", "estimatesmartfee").EstimateSmartFee(context.Background(), 2, "conservative")
+	if err != nil || fee.FeeRate != 0.00012345 || fee.Blocks != 2 {
+		t.Errorf("EstimateSmartFee = %+v, %v", fee, err)
+	}
+	fee, err = recordedClient(t, "estimatesmartfee_nodata", "estimatesmartfee").EstimateSmartFee(context.Background(), 2, "")
+	if err != nil || fee.FeeRate != 0 || len(fee.Errors) != 1 {
+		t.Errorf("EstimateSmartFee without data = %+v, %v", fee, err)
+	}
+}
+
+func TestRawTransactionRPCs(t *testing.T) {
+	ctx := context.Background()
+
+	address, err := recordedClient(t, "getrawchangeaddress", "getrawchangeaddress").GetRawChangeAddress(ctx, "legacy")
+	if err != nil || address != "1PMycacnJaSqwwJqjawXBErnLsZ7RkXUAs" {
+		t.Errorf("GetRawChangeAddress = %q, %v", address, err)
+	}
+
+	hex, err := recordedClient(t, "createrawtransaction", "createrawtransaction").CreateRawTransaction(ctx,
+		[]TxInput{{TxID: "b3a2f1e0", Vout: 1}}, map[string]float64{address: 0.0006}, true)
+	if err != nil || len(hex) != 170 {
+		t.Errorf("CreateRawTransaction = %q, %v", hex, err)
+	}
+
+	addInputs := false
+	funded, err := recordedClient(t, "fundrawtransaction", "fundrawtransaction").FundRawTransaction(ctx, hex, &FundRawTransactionOptions{AddInputs: &addInputs, FeeRate: 2})
+	if err != nil || funded.Fee != 0.00000452 || funded.ChangePos != 1 || len(funded.Hex) <= len(hex) {
+		t.Errorf("FundRawTransaction = %+v, %v", funded, err)
+	}
+
+	signed, err := recordedClient(t, "signrawtransactionwithwallet", "signrawtransactionwithwallet").SignRawTransactionWithWallet(ctx, hex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signed.Complete || len(signed.Errors) != 0 || len(signed.Hex) <= len(hex) {
+		t.Errorf("unexpected sign result %+v", signed)
+	}
+
+	decoded, err := recordedClient(t, "decoderawtransaction", "decoderawtransaction").DecodeRawTransaction(ctx, signed.Hex)
+	if err != nil || len(decoded.TxID) != 64 || decoded.VSize != 191 || len(decoded.Vin) != 1 || decoded.Vout[0].ScriptPubKey.Address != address {
+		t.Errorf("DecodeRawTransaction = %+v, %v", decoded, err)
+	}
+
+	txid, err := recordedClient(t, "sendrawtransaction", "sendrawtransaction").SendRawTransaction(ctx, signed.Hex, nil)
+	if err != nil || len(txid) != 64 {
+		t.Errorf("SendRawTransaction = %q, %v", txid, err)
+	}
+}
+
+func TestPSBTRPCs(t *testing.T) {
+	ctx := context.Background()
+	addInputs := false
+	funded, err := recordedClient(t, "walletcreatefundedpsbt", "walletcreatefundedpsbt").WalletCreateFundedPSBT(ctx,
+		[]TxInput{{TxID: "b3a2f1e0", Vout: 1}}, map[string]float64{"1PMycacnJaSqwwJqjawXBErnLsZ7RkXUAs": 0.0006}, &FundRawTransactionOptions{AddInputs: &addInputs, FeeRate: 2})
+	if err != nil || !strings.HasPrefix(funded.PSBT, "cHNidP8") || funded.Fee != 0.00000452 || funded.ChangePos != 1 {
+		t.Errorf("WalletCreateFundedPSBT = %+v, %v", funded, err)
+	}
+
+	processed, err := recordedClient(t, "walletprocesspsbt", "walletprocesspsbt").WalletProcessPSBT(ctx, funded.PSBT, true)
+	if err != nil || !processed.Complete || len(processed.PSBT) <= len(funded.PSBT) {
+		t.Errorf("WalletProcessPSBT = %+v, %v", processed, err)
+	}
+
+	final, err := recordedClient(t, "finalizepsbt", "finalizepsbt").FinalizePSBT(ctx, processed.PSBT)
+	if err != nil || !final.Complete || !strings.HasPrefix(final.Hex, "02000000") || final.PSBT != "" {
+		t.Errorf("FinalizePSBT = %+v, %v", final, err)
+	}
+
+	decoded, err := recordedClient(t, "decodepsbt", "decodepsbt").DecodePSBT(ctx, funded.PSBT)
+	if err != nil || len(decoded.Tx.Vin) != 1 || len(decoded.Tx.Vout) != 2 || decoded.Inputs[0].WitnessUTXO == nil || decoded.Inputs[0].WitnessUTXO.Amount != 0.00149888 || decoded.Fee != 0.00000452 {
+		t.Errorf("DecodePSBT = %+v, %v", decoded, err)
+	}
+
+	analyzed, err := recordedClient(t, "analyzepsbt", "analyzepsbt").AnalyzePSBT(ctx, funded.PSBT)
+	if err != nil || analyzed.EstimatedVSize != 226 || analyzed.EstimatedFeeRate != 0.00002 || analyzed.Next != "signer" || analyzed.Inputs[0].IsFinal {
+		t.Errorf("AnalyzePSBT = %+v, %v", analyzed, err)
+	}
+}
+
+func TestMaxFeeRateParam(t *testing.T) {
+	// maxFeeRate 为 nil 时不传参数，由节点使用自己的默认值
+	var params [][]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		params = append(params, req.Params)
+		if req.Method == "testmempoolaccept" {
+			w.Write([]byte(`{"result":[],"error":null,"id":1}`))
+			return
+		}
+		w.Write([]byte(`{"result":"txid","error":null,"id":1}`))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	c := NewClient(srv.URL)
+	noLimit := 0.0
+	if _, err := c.SendRawTransaction(ctx, "0200", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendRawTransaction(ctx, "0200", &noLimit); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.TestMempoolAccept(ctx, []string{"0200"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]interface{}{{"0200"}, {"0200", 0.0}, {[]interface{}{"0200"}}}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("params = %v, want %v", params, want)
+	}
+}
+
+func TestTestMempoolAccept(t *testing.T) {
+	ctx := context.Background()
+	results, err := recordedClient(t, "testmempoolaccept", "testmempoolaccept").TestMempoolAccept(ctx, []string{"0200"}, nil)
+	if err != nil || len(results) != 1 || !results[0].Allowed || results[0].VSize != 226 || results[0].Fees == nil || results[0].Fees.Base != 0.00000452 {
+		t.Errorf("TestMempoolAccept = %+v, %v", results, err)
+	}
+
+	results, err = recordedClient(t, "testmempoolaccept_rejected", "testmempoolaccept").TestMempoolAccept(ctx, []string{"0200"}, nil)
+	if err != nil || len(results) != 1 || results[0].Allowed || results[0].RejectReason != "too-long-mempool-chain" {
+		t.Errorf("TestMempoolAccept = %+v, %v", results, err)
+	}
+}
@@ -0,0 +1,55 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 常用的 bitcoind RPC 错误码，见 src/rpc/protocol.h
+const (
+	ErrCodeMisc                 = -1
+	ErrCodeTypeError            = -3
+	ErrCodeInvalidAddress       = -5
+	ErrCodeOutOfMemory          = -7
+	ErrCodeInvalidParameter     = -8
+	ErrCodeDatabaseError        = -20
+	ErrCodeDeserialization      = -22
+	ErrCodeVerify               = -25
+	ErrCodeVerifyRejected       = -26
+	ErrCodeVerifyAlreadyInChain = -27
+	ErrCodeInWarmup             = -28
+	ErrCodeMethodNotFound       = -32601
+	ErrCodeWallet               = -4
+	ErrCodeInsufficientFunds    = -6
+	ErrCodeWalletNotFound       = -18
+	ErrCodeWalletNotSpecified   = -19
+)
+
+// Error 是节点返回的 RPC 错误，保留原始错误码
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("RPC Error %d: %s", e.Code, e.Message)
+}
+
+// HTTPError 表示节点返回了非 JSON-RPC 的 HTTP 错误（如认证失败的 401）
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("HTTP status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("HTTP status %d: %s", e.StatusCode, e.Body)
+}
+
+// IsCode 判断 err 是否为指定错误码的 RPC 错误
+func IsCode(err error, code int) bool {
+	var rpcErr *Error
+	return errors.As(err, &rpcErr) && rpcErr.Code == code
+}
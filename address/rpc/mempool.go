@@ -0,0 +1,79 @@
+package rpc
+
+import "context"
+
+// GetRawMempool 返回交易池中全部交易的 txid
+func (c *Client) GetRawMempool(ctx context.Context) ([]string, error) {
+	var txids []string
+	err := c.Call(ctx, "getrawmempool", &txids, false)
+	return txids, err
+}
+
+// MempoolFees 是 getmempoolentry 中以 BTCW 为单位的各项手续费
+type MempoolFees struct {
+	Base       float64 `json:"base"`
+	Modified   float64 `json:"modified"`
+	Ancestor   float64 `json:"ancestor"`
+	Descendant float64 `json:"descendant"`
+}
+
+// MempoolEntry 是 getmempoolentry 的结果，大小均为虚拟大小（vB）
+type MempoolEntry struct {
+	VSize             int64       `json:"vsize"`
+	Weight            int64       `json:"weight"`
+	Time              int64       `json:"time"`
+	Height            int64       `json:"height"`
+	DescendantCount   int64       `json:"descendantcount"`
+	DescendantSize    int64       `json:"descendantsize"`
+	AncestorCount     int64       `json:"ancestorcount"`
+	AncestorSize      int64       `json:"ancestorsize"`
+	WTxID             string      `json:"wtxid"`
+	Fees              MempoolFees `json:"fees"`
+	Depends           []string    `json:"depends"`
+	SpentBy           []string    `json:"spentby"`
+	BIP125Replaceable bool        `json:"bip125-replaceable"`
+	Unbroadcast       bool        `json:"unbroadcast"`
+}
+
+// GetMempoolEntry 返回交易池中交易的信息，交易不在交易池中时返回 ErrCodeInvalidAddress 错误
+func (c *Client) GetMempoolEntry(ctx context.Context, txid string) (*MempoolEntry, error) {
+	var entry MempoolEntry
+	if err := c.Call(ctx, "getmempoolentry", &entry, txid); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetMempoolAncestors 返回交易池中 txid 的全部祖先交易的信息，以 txid 为键，不包含交易自身
+func (c *Client) GetMempoolAncestors(ctx context.Context, txid string) (map[string]MempoolEntry, error) {
+	var entries map[string]MempoolEntry
+	err := c.Call(ctx, "getmempoolancestors", &entries, txid, true)
+	return entries, err
+}
+
+// GetRawMempoolVerbose 返回交易池中全部交易的信息，以 txid 为键
+func (c *Client) GetRawMempoolVerbose(ctx context.Context) (map[string]MempoolEntry, error) {
+	var entries map[string]MempoolEntry
+	err := c.Call(ctx, "getrawmempool", &entries, true)
+	return entries, err
+}
+
+// SmartFee 是 estimatesmartfee 的结果，FeeRate 单位为 BTCW/kvB，无法估算时为 0 且 Errors 非空
+type SmartFee struct {
+	FeeRate float64  `json:"feerate"`
+	Errors  []string `json:"errors"`
+	Blocks  int64    `json:"blocks"`
+}
+
+// EstimateSmartFee 估算在 confTarget 个区块内确认所需的费率，estimateMode 为 "economical" 或 "conservative"，为空时使用节点默认值
+func (c *Client) EstimateSmartFee(ctx context.Context, confTarget int, estimateMode string) (*SmartFee, error) {
+	params := []interface{}{confTarget}
+	if estimateMode != "" {
+		params = append(params, estimateMode)
+	}
+	var fee SmartFee
+	if err := c.Call(ctx, "estimatesmartfee", &fee, params...); err != nil {
+		return nil, err
+	}
+	return &fee, nil
+}
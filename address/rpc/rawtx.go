@@ -0,0 +1,154 @@
+package rpc
+
+import "context"
+
+// TxInput 是 createrawtransaction 的一个输入
+type TxInput struct {
+	TxID     string  `json:"txid"`
+	Vout     uint32  `json:"vout"`
+	Sequence *uint32 `json:"sequence,omitempty"`
+}
+
+// ScriptPubKey 是交易输出的锁定脚本
+type ScriptPubKey struct {
+	Hex     string `json:"hex"`
+	Address string `json:"address"`
+	Type    string `json:"type"`
+}
+
+// TxOutput 是解码后交易的一个输出
+type TxOutput struct {
+	Value        float64      `json:"value"`
+	N            uint32       `json:"n"`
+	ScriptPubKey ScriptPubKey `json:"scriptPubKey"`
+}
+
+// DecodedTransaction 是 decoderawtransaction 的结果中本工具用到的字段
+type DecodedTransaction struct {
+	TxID     string     `json:"txid"`
+	Hash     string     `json:"hash"`
+	Size     int        `json:"size"`
+	VSize    int        `json:"vsize"`
+	Weight   int        `json:"weight"`
+	Locktime uint32     `json:"locktime"`
+	Vin      []TxInput  `json:"vin"`
+	Vout     []TxOutput `json:"vout"`
+}
+
+// FinalizePSBTResult 是 finalizepsbt 的结果，Complete 为 true 时 Hex 为可以广播的交易
+type FinalizePSBTResult struct {
+	PSBT     string `json:"psbt"`
+	Hex      string `json:"hex"`
+	Complete bool   `json:"complete"`
+}
+
+// PSBTUTXO 是 PSBT 输入花费的输出
+type PSBTUTXO struct {
+	Amount       float64      `json:"amount"`
+	ScriptPubKey ScriptPubKey `json:"scriptPubKey"`
+}
+
+// PSBTInput 是 decodepsbt 结果中的一个输入
+type PSBTInput struct {
+	WitnessUTXO *PSBTUTXO `json:"witness_utxo,omitempty"`
+}
+
+// DecodedPSBT 是 decodepsbt 的结果中本工具用到的字段，Fee 在全部输入都有 UTXO 信息时才有
+type DecodedPSBT struct {
+	Tx     DecodedTransaction `json:"tx"`
+	Inputs []PSBTInput        `json:"inputs"`
+	Fee    float64            `json:"fee"`
+}
+
+// AnalyzePSBTInput 是 analyzepsbt 结果中一个输入的状态
+type AnalyzePSBTInput struct {
+	HasUTXO bool   `json:"has_utxo"`
+	IsFinal bool   `json:"is_final"`
+	Next    string `json:"next"`
+}
+
+// AnalyzePSBTResult 是 analyzepsbt 的结果，EstimatedFeeRate 以 BTCW/kvB 为单位，Next 是下一步需要的角色
+type AnalyzePSBTResult struct {
+	Inputs           []AnalyzePSBTInput `json:"inputs"`
+	EstimatedVSize   int                `json:"estimated_vsize"`
+	EstimatedFeeRate float64            `json:"estimated_feerate"`
+	Fee              float64            `json:"fee"`
+	Next             string             `json:"next"`
+	Error            string             `json:"error"`
+}
+
+// TestMempoolAcceptResult 是 testmempoolaccept 对一笔交易的结果，Allowed 为 false 时 RejectReason 为拒绝原因
+type TestMempoolAcceptResult struct {
+	TxID         string       `json:"txid"`
+	WTxID        string       `json:"wtxid"`
+	Allowed      bool         `json:"allowed"`
+	VSize        int          `json:"vsize"`
+	Fees         *MempoolFees `json:"fees,omitempty"`
+	RejectReason string       `json:"reject-reason"`
+}
+
+// CreateRawTransaction 创建未签名的交易，outputs 为 地址 -> BTCW 数量，返回交易的十六进制编码
+func (c *Client) CreateRawTransaction(ctx context.Context, inputs []TxInput, outputs map[string]float64, replaceable bool) (string, error) {
+	var hex string
+	err := c.Call(ctx, "createrawtransaction", &hex, inputs, outputs, 0, replaceable)
+	return hex, err
+}
+
+// SendRawTransaction 广播已签名的交易，maxFeeRate 为允许的最高费率（BTCW/kvB），0 表示不限制；
+// 为 nil 时不传，由节点使用自己的 -maxfeerate 默认值
+func (c *Client) SendRawTransaction(ctx context.Context, hex string, maxFeeRate *float64) (string, error) {
+	params := []interface{}{hex}
+	if maxFeeRate != nil {
+		params = append(params, *maxFeeRate)
+	}
+	var txid string
+	err := c.Call(ctx, "sendrawtransaction", &txid, params...)
+	return txid, err
+}
+
+// DecodeRawTransaction 解码交易，签名后广播前可以由此得到 txid
+func (c *Client) DecodeRawTransaction(ctx context.Context, hex string) (*DecodedTransaction, error) {
+	var result DecodedTransaction
+	if err := c.Call(ctx, "decoderawtransaction", &result, hex); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// FinalizePSBT 完成已签名的 PSBT，全部输入都已签名时返回交易的十六进制编码
+func (c *Client) FinalizePSBT(ctx context.Context, psbt string) (*FinalizePSBTResult, error) {
+	var result FinalizePSBTResult
+	if err := c.Call(ctx, "finalizepsbt", &result, psbt, true); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DecodePSBT 解码 PSBT
+func (c *Client) DecodePSBT(ctx context.Context, psbt string) (*DecodedPSBT, error) {
+	var result DecodedPSBT
+	if err := c.Call(ctx, "decodepsbt", &result, psbt); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// AnalyzePSBT 分析 PSBT 的签名状态，并估算完成后的虚拟大小和费率
+func (c *Client) AnalyzePSBT(ctx context.Context, psbt string) (*AnalyzePSBTResult, error) {
+	var result AnalyzePSBTResult
+	if err := c.Call(ctx, "analyzepsbt", &result, psbt); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// TestMempoolAccept 检查已签名的交易能否进入交易池，不广播。maxFeeRate 与 SendRawTransaction 相同
+func (c *Client) TestMempoolAccept(ctx context.Context, rawTxs []string, maxFeeRate *float64) ([]TestMempoolAcceptResult, error) {
+	params := []interface{}{rawTxs}
+	if maxFeeRate != nil {
+		params = append(params, *maxFeeRate)
+	}
+	var results []TestMempoolAcceptResult
+	err := c.Call(ctx, "testmempoolaccept", &results, params...)
+	return results, err
+}
@@ -0,0 +1,172 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RetryPolicy 控制节点暂时不可用时的重试，零值不重试
+type RetryPolicy struct {
+	// MaxRetries 是第一次失败后最多重试的次数
+	MaxRetries int
+	// Initial 是第一次重试前的等待时间，之后每次加倍，默认 500ms
+	Initial time.Duration
+	// Max 是等待时间的上限，默认 30s
+	Max time.Duration
+	// OnRetry 在每次重试前调用，可用于记录日志
+	OnRetry func(method string, attempt int, wait time.Duration, err error)
+}
+
+// WithRetry 让客户端按 policy 重试网络错误、预热中（-28）和工作队列已满（503）。
+// 非幂等的调用（如 sendmany）只在确定节点没有执行时重试：连接未建立、-28 或 503
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithFailover 让读取区块链和交易池的调用在重试后仍因节点不可用而失败时改发到 secondary。
+// 钱包客户端（Wallet）和读取钱包的节点级调用（如 listwallets）不做故障转移，钱包只存在于一个节点上
+func WithFailover(secondary *Client) Option {
+	return func(c *Client) {
+		c.failover = secondary
+	}
+}
+
+// readOnlyMethods 是不改变节点状态、只读取区块链和交易池的方法，可以重试，也可以发到备用节点
+var readOnlyMethods = map[string]bool{
+	"getblockcount":        true,
+	"getblockhash":         true,
+	"getblockheader":       true,
+	"getnetworkhashps":     true,
+	"getrawmempool":        true,
+	"getmempoolentry":      true,
+	"getmempoolancestors":  true,
+	"estimatesmartfee":     true,
+	"validateaddress":      true,
+	"decoderawtransaction": true,
+	"finalizepsbt":         true,
+	"decodepsbt":           true,
+	"analyzepsbt":          true,
+	"testmempoolaccept":    true,
+}
+
+// walletReadMethods 只读取钱包状态，可以重试，但不能发到备用节点：备用节点上的同名钱包不是同一个钱包，
+// listwallets 也只能列出本节点加载的钱包
+var walletReadMethods = map[string]bool{
+	"listwallets":           true,
+	"listunspent":           true,
+	"listreceivedbyaddress": true,
+	"getbalances":           true,
+	"gettransaction":        true,
+	"listtransactions":      true,
+	"listsinceblock":        true,
+	"listlockunspent":       true,
+}
+
+// idempotentMethods 会改变状态，但重复执行的结果相同，可以重试，不能发到备用节点
+var idempotentMethods = map[string]bool{
+	"createrawtransaction":         true,
+	"signrawtransactionwithwallet": true,
+	"walletprocesspsbt":            true,
+	"sendrawtransaction":           true, // 同一交易重复广播返回同一 txid
+}
+
+// isReadOnly 返回 methods 是否全部是只读方法
+func isReadOnly(methods []string) bool {
+	for _, m := range methods {
+		if !readOnlyMethods[m] {
+			return false
+		}
+	}
+	return true
+}
+
+// isIdempotent 返回 methods 是否全部可以安全重复执行
+func isIdempotent(methods []string) bool {
+	for _, m := range methods {
+		if !readOnlyMethods[m] && !walletReadMethods[m] && !idempotentMethods[m] {
+			return false
+		}
+	}
+	return true
+}
+
+// transient 返回 err 是否由节点暂时不可用导致：网络错误、预热中或工作队列已满
+func transient(err error) bool {
+	var urlErr *url.Error
+	var httpErr *HTTPError
+	switch {
+	case IsCode(err, ErrCodeInWarmup):
+		return true
+	case errors.As(err, &httpErr):
+		return httpErr.StatusCode == http.StatusServiceUnavailable
+	case errors.As(err, &urlErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	}
+	return false
+}
+
+// notExecuted 返回失败的请求是否确定没有被节点执行
+func notExecuted(err error) bool {
+	var opErr *net.OpError
+	var httpErr *HTTPError
+	switch {
+	case IsCode(err, ErrCodeInWarmup):
+		return true
+	case errors.As(err, &httpErr):
+		return httpErr.StatusCode == http.StatusServiceUnavailable
+	case errors.As(err, &opErr):
+		return opErr.Op == "dial"
+	}
+	return false
+}
+
+// backoff 返回第 attempt 次重试（从 0 开始）前的等待时间
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	wait, max := p.Initial, p.Max
+	if wait <= 0 {
+		wait = 500 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 30 * time.Second
+	}
+	for i := 0; i < attempt && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		wait = max
+	}
+	return wait
+}
+
+// do 调用 send，按重试策略重试，仍失败时只读调用转到备用节点。methods 是本次请求包含的方法
+func (c *Client) do(ctx context.Context, methods []string, send func(c *Client) error) error {
+	err := send(c)
+	for attempt := 0; err != nil && attempt < c.retry.MaxRetries; attempt++ {
+		if ctx.Err() != nil || !transient(err) || !(notExecuted(err) || isIdempotent(methods)) {
+			return err
+		}
+		wait := c.retry.backoff(attempt)
+		if c.retry.OnRetry != nil {
+			c.retry.OnRetry(methods[0], attempt+1, wait, err)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		err = send(c)
+	}
+	if err != nil && c.failover != nil && transient(err) && isReadOnly(methods) && ctx.Err() == nil {
+		return c.failover.do(ctx, methods, send)
+	}
+	return err
+}
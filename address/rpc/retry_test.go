@@ -0,0 +1,155 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer 前 failures 次请求由 fail 处理，之后由 ok 处理。调用计数由处理函数并发修改，只能用 atomic 读写
+func flakyServer(t *testing.T, failures int32, fail, ok http.HandlerFunc) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			fail(w, r)
+			return
+		}
+		ok(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+// respond 返回以 result 为调用结果的处理函数
+func respond(result string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":` + result + `,"error":null,"id":1}`))
+	}
+}
+
+// warmingUp 模拟正在加载区块索引的节点
+func warmingUp(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte(`{"result":null,"error":{"code":-28,"message":"Loading block index..."},"id":1}`))
+}
+
+// dropConnection 在读取请求后直接断开连接，节点可能已经执行了调用
+func dropConnection(w http.ResponseWriter, r *http.Request) {
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err == nil {
+		conn.Close()
+	}
+}
+
+var fastRetry = RetryPolicy{MaxRetries: 3, Initial: time.Millisecond, Max: 2 * time.Millisecond}
+
+func TestRetryWarmup(t *testing.T) {
+	srv, calls := flakyServer(t, 2, warmingUp, respond("812345"))
+	var retries []int
+	policy := fastRetry
+	policy.OnRetry = func(method string, attempt int, wait time.Duration, err error) {
+		if method != "getblockcount" || !IsCode(err, ErrCodeInWarmup) {
+			t.Errorf("OnRetry(%s, %v)", method, err)
+		}
+		retries = append(retries, attempt)
+	}
+	height, err := NewClient(srv.URL, WithRetry(policy)).GetBlockCount(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height != 812345 || atomic.LoadInt32(calls) != 3 || len(retries) != 2 || retries[1] != 2 {
+		t.Errorf("height = %d, calls = %d, retries = %v", height, atomic.LoadInt32(calls), retries)
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	srv, calls := flakyServer(t, 10, warmingUp, respond("1"))
+	_, err := NewClient(srv.URL, WithRetry(fastRetry)).GetBlockCount(context.Background())
+	if !IsCode(err, ErrCodeInWarmup) || atomic.LoadInt32(calls) != 4 {
+		t.Errorf("err = %v, calls = %d", err, atomic.LoadInt32(calls))
+	}
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	// 连接断开时 sendmany 可能已经广播，不能重试
+	srv, calls := flakyServer(t, 1, dropConnection, respond(`{"txid":"txid"}`))
+	c := NewClient(srv.URL, WithRetry(fastRetry)).Wallet("btcw1")
+	if _, err := c.SendMany(context.Background(), map[string]float64{"addr": 1}, SendManyOptions{}); err == nil || atomic.LoadInt32(calls) != 1 {
+		t.Errorf("err = %v, calls = %d", err, atomic.LoadInt32(calls))
+	}
+
+	// -28 表示节点没有执行，可以重试
+	srv, calls = flakyServer(t, 1, warmingUp, respond(`{"txid":"txid"}`))
+	c = NewClient(srv.URL, WithRetry(fastRetry)).Wallet("btcw1")
+	if res, err := c.SendMany(context.Background(), map[string]float64{"addr": 1}, SendManyOptions{}); err != nil || res.TxID != "txid" || atomic.LoadInt32(calls) != 2 {
+		t.Errorf("result = %+v, err = %v, calls = %d", res, err, atomic.LoadInt32(calls))
+	}
+
+	// 只读调用在连接断开后重试
+	srv, calls = flakyServer(t, 1, dropConnection, respond("7"))
+	if height, err := NewClient(srv.URL, WithRetry(fastRetry)).GetBlockCount(context.Background()); err != nil || height != 7 || atomic.LoadInt32(calls) != 2 {
+		t.Errorf("height = %d, err = %v, calls = %d", height, err, atomic.LoadInt32(calls))
+	}
+}
+
+func TestRetryNotTransient(t *testing.T) {
+	srv, calls := flakyServer(t, 1, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}, respond("1"))
+	if _, err := NewClient(srv.URL, WithRetry(fastRetry)).GetBlockCount(context.Background()); err == nil || atomic.LoadInt32(calls) != 1 {
+		t.Errorf("err = %v, calls = %d", err, atomic.LoadInt32(calls))
+	}
+}
+
+func TestFailover(t *testing.T) {
+	// 取一个没有监听的端口作为不可用的主节点
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	down := "http://" + l.Addr().String()
+	l.Close()
+
+	secondary, calls := flakyServer(t, 0, nil, respond("42"))
+	c := NewClient(down, WithRetry(RetryPolicy{MaxRetries: 1, Initial: time.Millisecond}), WithFailover(NewClient(secondary.URL)))
+	if height, err := c.GetBlockCount(context.Background()); err != nil || height != 42 || atomic.LoadInt32(calls) != 1 {
+		t.Errorf("height = %d, err = %v, calls = %d", height, err, atomic.LoadInt32(calls))
+	}
+	if _, err := c.Wallet("btcw1").SendMany(context.Background(), map[string]float64{"addr": 1}, SendManyOptions{}); err == nil || atomic.LoadInt32(calls) != 1 {
+		t.Errorf("sendmany failed over: err = %v, calls = %d", err, atomic.LoadInt32(calls))
+	}
+
+	// 钱包客户端不做故障转移
+	if _, err := c.Wallet("btcw1").GetBlockCount(context.Background()); err == nil || atomic.LoadInt32(calls) != 1 {
+		t.Errorf("wallet client failed over: err = %v, calls = %d", err, atomic.LoadInt32(calls))
+	}
+	// 读取钱包的节点级调用也不做故障转移
+	if _, err := c.ListWallets(context.Background()); err == nil || atomic.LoadInt32(calls) != 1 {
+		t.Errorf("listwallets failed over: err = %v, calls = %d", err, atomic.LoadInt32(calls))
+	}
+}
+
+func TestBatchRetry(t *testing.T) {
+	srv, calls := flakyServer(t, 1, dropConnection, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"result":"hash","error":null,"id":1}]`))
+	})
+	c := NewClient(srv.URL, WithRetry(fastRetry))
+	b := c.NewBatch()
+	var hash string
+	b.Add("getblockhash", &hash, 1)
+	if err := b.Send(context.Background()); err != nil || hash != "hash" || atomic.LoadInt32(calls) != 2 {
+		t.Errorf("hash = %q, err = %v, calls = %d", hash, err, atomic.LoadInt32(calls))
+	}
+
+	atomic.StoreInt32(calls, 0)
+	b = c.Wallet("btcw1").NewBatch()
+	b.Add("sendmany", nil, "", map[string]float64{"addr": 1})
+	if err := b.Send(context.Background()); err == nil || atomic.LoadInt32(calls) != 1 {
+		t.Errorf("sendmany batch retried: err = %v, calls = %d", err, atomic.LoadInt32(calls))
+	}
+}
@@ -0,0 +1,110 @@
+package rpctest
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"address/rpc"
+)
+
+func (s *Server) getBlockCount(_ string, _ []json.RawMessage) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tip().height, nil
+}
+
+func (s *Server) getBlockHash(_ string, params []json.RawMessage) (interface{}, error) {
+	height := int64(-1)
+	if err := arg(params, 0, &height); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if height < 0 || height >= int64(len(s.blocks)) {
+		return nil, rpcError(rpc.ErrCodeInvalidParameter, "Block height out of range")
+	}
+	return s.blocks[height].hash, nil
+}
+
+func (s *Server) getBlockHeader(_ string, params []json.RawMessage) (interface{}, error) {
+	var hash string
+	if err := arg(params, 0, &hash); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, b := range s.blocks {
+		if b.hash != hash {
+			continue
+		}
+		header := rpc.BlockHeader{
+			Hash:          b.hash,
+			Confirmations: s.tip().height - b.height + 1,
+			Height:        b.height,
+			Version:       0x20000000,
+			VersionHex:    "20000000",
+			MerkleRoot:    b.hash,
+			Time:          b.time,
+			MedianTime:    b.time,
+			Bits:          "1d00ffff",
+			Difficulty:    1,
+			Chainwork:     fmt.Sprintf("%064x", (b.height+1)<<32),
+			NTx:           len(b.txids) + 1,
+		}
+		if i > 0 {
+			header.PreviousBlockHash = s.blocks[i-1].hash
+		}
+		if i+1 < len(s.blocks) {
+			header.NextBlockHash = s.blocks[i+1].hash
+		}
+		return header, nil
+	}
+	return nil, rpcError(rpc.ErrCodeInvalidAddress, "Block not found")
+}
+
+func (s *Server) getNetworkHashPS(_ string, _ []json.RawMessage) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hashPS, nil
+}
+
+func (s *Server) prioritiseTransaction(_ string, params []json.RawMessage) (interface{}, error) {
+	var txid string
+	var dummy, feeDelta float64
+	if err := args(params, &txid, &dummy, &feeDelta); err != nil {
+		return nil, err
+	}
+	if len(txid) != 64 {
+		return nil, rpcError(rpc.ErrCodeInvalidParameter, "txid must be of length 64 (not %d, for '%s')", len(txid), txid)
+	}
+	if dummy != 0 {
+		return nil, rpcError(rpc.ErrCodeInvalidParameter, "Priority is no longer supported, dummy argument to prioritisetransaction must be 0.")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.priorities[txid] += feeDelta
+	return true, nil
+}
+
+// generate 是 BitcoinPoW 的挖矿RPC，模拟节点立即产生 nblocks 个区块（默认 1 个）
+func (s *Server) generate(_ string, params []json.RawMessage) (interface{}, error) {
+	nblocks := 1
+	if err := arg(params, 0, &nblocks); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mine(nblocks, true), nil
+}
+
+func (s *Server) validateAddress(_ string, params []json.RawMessage) (interface{}, error) {
+	var address string
+	if err := arg(params, 0, &address); err != nil {
+		return nil, err
+	}
+	if !validAddress(address) {
+		return rpc.ValidateAddressResult{Error: "Invalid or unsupported Segwit (Bech32) or Base58 encoding."}, nil
+	}
+	// 模拟节点的地址都是 P2WPKH
+	return rpc.ValidateAddressResult{IsValid: true, Address: address, ScriptPubKey: p2wpkhScript, IsWitness: true}, nil
+}
@@ -0,0 +1,138 @@
+package rpctest
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"address/rpc"
+)
+
+func (s *Server) getRawMempool(_ string, params []json.RawMessage) (interface{}, error) {
+	var verbose bool
+	if err := arg(params, 0, &verbose); err != nil {
+		return nil, err
+	}
+	if !verbose {
+		return s.Mempool(), nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make(map[string]rpc.MempoolEntry, len(s.mempool))
+	for txid := range s.mempool {
+		entries[txid] = s.mempoolEntry(s.txs[txid])
+	}
+	return entries, nil
+}
+
+// estimateSmartFee 返回 SetSmartFee 设置的费率，未设置时与数据不足的节点一样返回错误信息
+func (s *Server) estimateSmartFee(_ string, params []json.RawMessage) (interface{}, error) {
+	var confTarget int
+	mode := "conservative"
+	if err := args(params, &confTarget, &mode); err != nil {
+		return nil, err
+	}
+	if confTarget < 1 || confTarget > 1008 {
+		return nil, rpcError(rpc.ErrCodeInvalidParameter, "Invalid conf_target, must be between 1 and 1008")
+	}
+	if mode = strings.ToLower(mode); mode != "unset" && mode != "economical" && mode != "conservative" {
+		return nil, rpcError(rpc.ErrCodeInvalidParameter, "Invalid estimate_mode parameter, must be one of: \"unset\", \"economical\", \"conservative\"")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.smartFee == 0 {
+		return rpc.SmartFee{Errors: []string{"Insufficient data or no feerate found"}}, nil
+	}
+	return rpc.SmartFee{FeeRate: toBTC(s.smartFee * 1000), Blocks: int64(confTarget)}, nil
+}
+
+func (s *Server) getMempoolEntry(_ string, params []json.RawMessage) (interface{}, error) {
+	var txid string
+	if err := arg(params, 0, &txid); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.mempool[txid] {
+		return nil, rpcError(rpc.ErrCodeInvalidAddress, "Transaction not in mempool")
+	}
+	return s.mempoolEntry(s.txs[txid]), nil
+}
+
+func (s *Server) getMempoolAncestors(_ string, params []json.RawMessage) (interface{}, error) {
+	var txid string
+	var verbose bool
+	if err := args(params, &txid, &verbose); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.mempool[txid] {
+		return nil, rpcError(rpc.ErrCodeInvalidAddress, "Transaction not in mempool")
+	}
+	ancestors := s.ancestors(s.txs[txid])
+	if !verbose {
+		txids := make([]string, 0, len(ancestors))
+		for id := range ancestors {
+			txids = append(txids, id)
+		}
+		sort.Strings(txids)
+		return txids, nil
+	}
+	entries := make(map[string]rpc.MempoolEntry, len(ancestors))
+	for id, a := range ancestors {
+		entries[id] = s.mempoolEntry(a)
+	}
+	return entries, nil
+}
+
+// mempoolEntry 构造交易池中交易 t 的 getmempoolentry 结果
+func (s *Server) mempoolEntry(t *tx) rpc.MempoolEntry {
+	entry := rpc.MempoolEntry{
+		VSize:             int64(t.vsize),
+		Weight:            int64(t.vsize) * 4,
+		Time:              t.time,
+		Height:            (t.time - genesisTime) / blockSpacing,
+		DescendantCount:   1,
+		DescendantSize:    int64(t.vsize),
+		AncestorCount:     1,
+		AncestorSize:      int64(t.vsize),
+		WTxID:             t.txid,
+		Depends:           []string{},
+		SpentBy:           []string{},
+		BIP125Replaceable: t.replaceable,
+	}
+	modified := t.fee + int64(s.priorities[t.txid])
+	ancestorFees, descendantFees := modified, modified
+	for _, a := range s.ancestors(t) {
+		entry.AncestorCount++
+		entry.AncestorSize += int64(a.vsize)
+		ancestorFees += a.fee + int64(s.priorities[a.txid])
+	}
+	for _, d := range s.descendants(t) {
+		entry.DescendantCount++
+		entry.DescendantSize += int64(d.vsize)
+		descendantFees += d.fee + int64(s.priorities[d.txid])
+	}
+	for _, in := range t.inputs {
+		if s.mempool[in.txid] && !contains(entry.Depends, in.txid) {
+			entry.Depends = append(entry.Depends, in.txid)
+		}
+	}
+	for txid := range s.mempool {
+		for _, in := range s.txs[txid].inputs {
+			if in.txid == t.txid && !contains(entry.SpentBy, txid) {
+				entry.SpentBy = append(entry.SpentBy, txid)
+			}
+		}
+	}
+	sort.Strings(entry.Depends)
+	sort.Strings(entry.SpentBy)
+	entry.Fees = rpc.MempoolFees{
+		Base:       toBTC(t.fee),
+		Modified:   toBTC(modified),
+		Ancestor:   toBTC(ancestorFees),
+		Descendant: toBTC(descendantFees),
+	}
+	return entry
+}
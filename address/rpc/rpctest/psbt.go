@@ -0,0 +1,166 @@
+package rpctest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"address/rpc"
+)
+
+// psbt 是模拟节点的 PSBT 编码，base64 编码的交易 JSON，只能由模拟节点自己解析
+func (r rawTx) psbt() string {
+	data, _ := json.Marshal(r)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func parsePSBT(s string) (rawTx, error) {
+	var r rawTx
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(data, &r)
+	}
+	if err != nil {
+		return r, rpcError(rpc.ErrCodeDeserialization, "TX decode failed %v", err)
+	}
+	return r, nil
+}
+
+func (s *Server) walletCreateFundedPSBT(walletName string, params []json.RawMessage) (interface{}, error) {
+	var inputs []rpc.TxInput
+	var outputs map[string]float64
+	var locktime int64
+	var opts rpc.FundRawTransactionOptions
+	if err := args(params, &inputs, &outputs, &locktime, &opts); err != nil {
+		return nil, err
+	}
+	// 与钱包的 -walletrbf 默认值一致
+	r, err := newRawTx(inputs, outputs, true)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := s.wallet(walletName)
+	if err != nil {
+		return nil, err
+	}
+	fee, changePos, err := s.fund(w, &r, opts)
+	if err != nil {
+		return nil, err
+	}
+	return rpc.WalletCreateFundedPSBTResult{PSBT: r.psbt(), Fee: toBTC(fee), ChangePos: changePos}, nil
+}
+
+func (s *Server) walletProcessPSBT(walletName string, params []json.RawMessage) (interface{}, error) {
+	var psbt string
+	sign := true
+	if err := args(params, &psbt, &sign); err != nil {
+		return nil, err
+	}
+	r, err := parsePSBT(psbt)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := s.wallet(walletName)
+	if err != nil {
+		return nil, err
+	}
+	if sign && !r.Signed {
+		s.sign(w, &r)
+	}
+	return rpc.WalletProcessPSBTResult{PSBT: r.psbt(), Complete: r.Signed}, nil
+}
+
+func (s *Server) finalizePSBT(_ string, params []json.RawMessage) (interface{}, error) {
+	var psbt string
+	if err := arg(params, 0, &psbt); err != nil {
+		return nil, err
+	}
+	r, err := parsePSBT(psbt)
+	if err != nil {
+		return nil, err
+	}
+	if !r.Signed {
+		return rpc.FinalizePSBTResult{PSBT: psbt}, nil
+	}
+	return rpc.FinalizePSBTResult{Hex: r.encode(), Complete: true}, nil
+}
+
+// psbtInputs 返回 r 花费的输出，输出不存在时 ok 为 false，调用者持有 s.mu
+func (s *Server) psbtInputs(r rawTx) (inputs []output, ok bool) {
+	for _, in := range r.Inputs {
+		o, ok := s.output(outpoint{txid: in.TxID, vout: in.Vout})
+		if !ok {
+			return nil, false
+		}
+		inputs = append(inputs, o)
+	}
+	return inputs, true
+}
+
+// psbtFee 返回 r 花费 inputs 的手续费（聪）
+func psbtFee(r rawTx, inputs []output) int64 {
+	var fee int64
+	for _, in := range inputs {
+		fee += in.amount
+	}
+	for _, o := range r.Outputs {
+		fee -= o.Amount
+	}
+	return fee
+}
+
+func (s *Server) decodePSBT(_ string, params []json.RawMessage) (interface{}, error) {
+	var psbt string
+	if err := arg(params, 0, &psbt); err != nil {
+		return nil, err
+	}
+	r, err := parsePSBT(psbt)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := rpc.DecodedPSBT{Tx: r.decode(), Inputs: []rpc.PSBTInput{}}
+	inputs, ok := s.psbtInputs(r)
+	for _, in := range inputs {
+		result.Inputs = append(result.Inputs, rpc.PSBTInput{WitnessUTXO: &rpc.PSBTUTXO{Amount: toBTC(in.amount), ScriptPubKey: rpc.ScriptPubKey{Address: in.address, Type: "witness_v0_keyhash"}}})
+	}
+	if ok {
+		result.Fee = toBTC(psbtFee(r, inputs))
+	}
+	return result, nil
+}
+
+func (s *Server) analyzePSBT(_ string, params []json.RawMessage) (interface{}, error) {
+	var psbt string
+	if err := arg(params, 0, &psbt); err != nil {
+		return nil, err
+	}
+	r, err := parsePSBT(psbt)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := "signer"
+	if r.Signed {
+		next = "extractor"
+	}
+	result := rpc.AnalyzePSBTResult{Inputs: []rpc.AnalyzePSBTInput{}, Next: next}
+	inputs, ok := s.psbtInputs(r)
+	if !ok {
+		result.Next, result.Error = "updater", "PSBT is missing input UTXO information"
+		return result, nil
+	}
+	for range inputs {
+		result.Inputs = append(result.Inputs, rpc.AnalyzePSBTInput{HasUTXO: true, IsFinal: r.Signed, Next: next})
+	}
+	fee := psbtFee(r, inputs)
+	result.EstimatedVSize = txVSize(len(r.Inputs), len(r.Outputs))
+	result.Fee = toBTC(fee)
+	result.EstimatedFeeRate = toBTC(fee * 1000 / int64(result.EstimatedVSize))
+	return result, nil
+}
@@ -0,0 +1,342 @@
+package rpctest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"address/rpc"
+)
+
+// defaultMaxFeeRate 是 sendrawtransaction 的 maxfeerate 默认值（BTCW/kvB）
+const defaultMaxFeeRate = 0.10
+
+// rawTx 是模拟节点的交易编码，十六进制编码的 JSON，只能由模拟节点自己解析
+type rawTx struct {
+	Inputs      []rawInput  `json:"inputs"`
+	Outputs     []rawOutput `json:"outputs"`
+	Replaceable bool        `json:"replaceable"`
+	Signed      bool        `json:"signed"`
+}
+
+type rawInput struct {
+	TxID string `json:"txid"`
+	Vout int    `json:"vout"`
+}
+
+type rawOutput struct {
+	Address string `json:"address"`
+	Amount  int64  `json:"amount"`
+}
+
+func (r rawTx) encode() string {
+	data, _ := json.Marshal(r)
+	return hex.EncodeToString(data)
+}
+
+// txid 与真实交易一样不包含签名，签名前后相同
+func (r rawTx) txid() string {
+	unsigned := r
+	unsigned.Signed = false
+	data, _ := json.Marshal(unsigned)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func decodeRawTx(s string) (rawTx, error) {
+	var r rawTx
+	data, err := hex.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(data, &r)
+	}
+	if err != nil {
+		return r, rpcError(rpc.ErrCodeDeserialization, "TX decode failed")
+	}
+	return r, nil
+}
+
+func (s *Server) createRawTransaction(_ string, params []json.RawMessage) (interface{}, error) {
+	var inputs []rpc.TxInput
+	var outputs map[string]float64
+	var locktime int64
+	var replaceable bool
+	if err := args(params, &inputs, &outputs, &locktime, &replaceable); err != nil {
+		return nil, err
+	}
+	r, err := newRawTx(inputs, outputs, replaceable)
+	if err != nil {
+		return nil, err
+	}
+	return r.encode(), nil
+}
+
+// newRawTx 按 createrawtransaction 的参数构造未签名的交易，输出按地址排序
+func newRawTx(inputs []rpc.TxInput, outputs map[string]float64, replaceable bool) (rawTx, error) {
+	r := rawTx{Replaceable: replaceable}
+	for _, in := range inputs {
+		if len(in.TxID) != 64 {
+			return r, rpcError(rpc.ErrCodeInvalidParameter, "txid must be of length 64 (not %d, for '%s')", len(in.TxID), in.TxID)
+		}
+		r.Inputs = append(r.Inputs, rawInput{TxID: in.TxID, Vout: int(in.Vout)})
+	}
+	addresses := make([]string, 0, len(outputs))
+	for address := range outputs {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	for _, address := range addresses {
+		if !validAddress(address) {
+			return r, rpcError(rpc.ErrCodeInvalidAddress, "Invalid BitcoinPoW address: %s", address)
+		}
+		r.Outputs = append(r.Outputs, rawOutput{Address: address, Amount: toSat(outputs[address])})
+	}
+	return r, nil
+}
+
+func (s *Server) decodeRawTransaction(_ string, params []json.RawMessage) (interface{}, error) {
+	var txHex string
+	if err := arg(params, 0, &txHex); err != nil {
+		return nil, err
+	}
+	r, err := decodeRawTx(txHex)
+	if err != nil {
+		return nil, err
+	}
+	return r.decode(), nil
+}
+
+// decode 返回 decoderawtransaction 格式的交易
+func (r rawTx) decode() rpc.DecodedTransaction {
+	vsize := txVSize(len(r.Inputs), len(r.Outputs))
+	result := rpc.DecodedTransaction{TxID: r.txid(), Hash: r.txid(), Size: vsize, VSize: vsize, Weight: 4 * vsize, Vin: []rpc.TxInput{}, Vout: []rpc.TxOutput{}}
+	for _, in := range r.Inputs {
+		result.Vin = append(result.Vin, rpc.TxInput{TxID: in.TxID, Vout: uint32(in.Vout)})
+	}
+	for i, o := range r.Outputs {
+		result.Vout = append(result.Vout, rpc.TxOutput{Value: toBTC(o.Amount), N: uint32(i), ScriptPubKey: rpc.ScriptPubKey{Address: o.Address, Type: "witness_v0_keyhash"}})
+	}
+	return result
+}
+
+func (s *Server) fundRawTransaction(walletName string, params []json.RawMessage) (interface{}, error) {
+	var txHex string
+	var opts rpc.FundRawTransactionOptions
+	if err := args(params, &txHex, &opts); err != nil {
+		return nil, err
+	}
+	r, err := decodeRawTx(txHex)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := s.wallet(walletName)
+	if err != nil {
+		return nil, err
+	}
+	fee, changePos, err := s.fund(w, &r, opts)
+	if err != nil {
+		return nil, err
+	}
+	return rpc.FundRawTransactionResult{Hex: r.encode(), Fee: toBTC(fee), ChangePos: changePos}, nil
+}
+
+// fund 按费率计算 r 的手续费并添加找零，返回手续费（聪）和找零位置。
+// add_inputs 为 true（没有输入时的默认值）时从已确认且没有锁定的 UTXO 中补充输入，lockUnspents 为 true 时锁定所有输入
+func (s *Server) fund(w *wallet, r *rawTx, opts rpc.FundRawTransactionOptions) (int64, int, error) {
+	var in, out int64
+	selected := make(map[outpoint]bool)
+	for _, i := range r.Inputs {
+		op := outpoint{txid: i.TxID, vout: i.Vout}
+		o, ok := s.output(op)
+		if !ok || s.owners[o.address] != w || s.spender(op) != nil {
+			return 0, 0, rpcError(rpc.ErrCodeWallet, "Not found pre-selected input %s:%d", i.TxID, i.Vout)
+		}
+		selected[op] = true
+		in += o.amount
+	}
+	for _, o := range r.Outputs {
+		out += o.Amount
+	}
+	rate, _ := s.walletFeeRate(opts.FeeRate, opts.ConfTarget)
+	fee := rate * int64(txVSize(len(r.Inputs), len(r.Outputs)+1))
+	addInputs := len(r.Inputs) == 0
+	if opts.AddInputs != nil {
+		addInputs = *opts.AddInputs
+	}
+	for _, c := range s.coins(w) {
+		if !addInputs || in >= out+fee {
+			break
+		}
+		if s.confirmations(c.tx) < 1 || selected[c.outpoint] || s.locked[c.outpoint] {
+			continue
+		}
+		r.Inputs = append(r.Inputs, rawInput{TxID: c.txid, Vout: c.vout})
+		in += c.amount
+		fee = rate * int64(txVSize(len(r.Inputs), len(r.Outputs)+1))
+	}
+	if len(r.Inputs) == 0 || in < out+fee {
+		return 0, 0, rpcError(rpc.ErrCodeInsufficientFunds, "Insufficient funds")
+	}
+
+	changePos := -1
+	if change := in - out - fee; change > dustLimit {
+		r.Outputs = append(r.Outputs, rawOutput{Address: s.newAddress(w, "", true), Amount: change})
+		changePos = len(r.Outputs) - 1
+	} else {
+		fee = in - out
+	}
+	if opts.Replaceable != nil {
+		r.Replaceable = *opts.Replaceable
+	}
+	if opts.LockUnspents {
+		for _, i := range r.Inputs {
+			s.locked[outpoint{txid: i.TxID, vout: i.Vout}] = true
+		}
+	}
+	return fee, changePos, nil
+}
+
+func (s *Server) signRawTransactionWithWallet(walletName string, params []json.RawMessage) (interface{}, error) {
+	var txHex string
+	if err := arg(params, 0, &txHex); err != nil {
+		return nil, err
+	}
+	r, err := decodeRawTx(txHex)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := s.wallet(walletName)
+	if err != nil {
+		return nil, err
+	}
+	result := rpc.SignRawTransactionResult{Errors: s.sign(w, &r)}
+	result.Hex, result.Complete = r.encode(), r.Signed
+	return result, nil
+}
+
+// sign 在钱包拥有 r 的全部输入时签名，返回无法签名的输入
+func (s *Server) sign(w *wallet, r *rawTx) []rpc.SignRawTransactionError {
+	errs := []rpc.SignRawTransactionError{}
+	for _, in := range r.Inputs {
+		o, ok := s.output(outpoint{txid: in.TxID, vout: in.Vout})
+		if !ok || s.owners[o.address] != w {
+			errs = append(errs, rpc.SignRawTransactionError{TxID: in.TxID, Vout: uint32(in.Vout), Error: "Input not found or already spent"})
+		}
+	}
+	r.Signed = len(errs) == 0
+	return errs
+}
+
+func (s *Server) sendRawTransaction(_ string, params []json.RawMessage) (interface{}, error) {
+	var txHex string
+	maxFeeRate := defaultMaxFeeRate
+	if err := args(params, &txHex, &maxFeeRate); err != nil {
+		return nil, err
+	}
+	r, err := decodeRawTx(txHex)
+	if err != nil {
+		return nil, err
+	}
+	if !r.Signed {
+		return nil, rpcError(rpc.ErrCodeVerify, "mandatory-script-verify-flag-failed (Operation not valid with the current stack size)")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 重复广播交易池中的交易返回同一 txid
+	if t, ok := s.txs[r.txid()]; ok && s.valid(t) {
+		if t.height >= 0 {
+			return nil, rpcError(rpc.ErrCodeVerifyAlreadyInChain, "Transaction already in block chain")
+		}
+		return t.txid, nil
+	}
+
+	t, err := s.accept(r, maxFeeRate)
+	if err != nil {
+		return nil, err
+	}
+	s.addTx(t)
+	return t.txid, nil
+}
+
+// accept 按交易池规则检查已签名的 r，返回可以加入交易池的交易，调用者持有 s.mu
+func (s *Server) accept(r rawTx, maxFeeRate float64) (*tx, error) {
+	t := &tx{
+		txid:        r.txid(),
+		vsize:       txVSize(len(r.Inputs), len(r.Outputs)),
+		height:      -1,
+		replaceable: r.Replaceable,
+		time:        s.tip().time,
+	}
+	var in, out int64
+	for _, i := range r.Inputs {
+		op := outpoint{txid: i.TxID, vout: i.Vout}
+		o, ok := s.output(op)
+		if !ok || s.spender(op) != nil {
+			return nil, rpcError(rpc.ErrCodeVerify, "bad-txns-inputs-missingorspent")
+		}
+		if w := s.owners[o.address]; w != nil {
+			t.wallet = w.name
+		}
+		t.inputs = append(t.inputs, op)
+		in += o.amount
+	}
+	for _, o := range r.Outputs {
+		t.outputs = append(t.outputs, output{address: o.Address, amount: o.Amount})
+		out += o.Amount
+	}
+	t.fee = in - out
+	switch {
+	case t.fee < 0:
+		return nil, rpcError(rpc.ErrCodeVerify, "bad-txns-in-belowout")
+	case t.fee < int64(t.vsize):
+		return nil, rpcError(rpc.ErrCodeVerifyRejected, "min relay fee not met, %d < %d", t.fee, t.vsize)
+	case maxFeeRate > 0 && float64(t.fee)/float64(t.vsize) > maxFeeRate*coin/1000:
+		return nil, rpcError(rpc.ErrCodeVerifyRejected, "Fee exceeds maximum configured by user (e.g. -maxtxfee, maxfeerate)")
+	}
+	if err := s.checkChainLimits(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *Server) testMempoolAccept(_ string, params []json.RawMessage) (interface{}, error) {
+	var rawTxs []string
+	maxFeeRate := defaultMaxFeeRate
+	if err := args(params, &rawTxs, &maxFeeRate); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	results := []rpc.TestMempoolAcceptResult{}
+	for _, txHex := range rawTxs {
+		r, err := decodeRawTx(txHex)
+		if err != nil {
+			return nil, err
+		}
+		result := rpc.TestMempoolAcceptResult{TxID: r.txid(), WTxID: r.txid()}
+		if t, ok := s.txs[r.txid()]; ok && s.valid(t) {
+			result.RejectReason = "txn-already-known"
+		} else if !r.Signed {
+			result.RejectReason = "mandatory-script-verify-flag-failed (Operation not valid with the current stack size)"
+		} else if t, err := s.accept(r, maxFeeRate); err != nil {
+			result.RejectReason = err.(*rpc.Error).Message
+		} else {
+			result.Allowed, result.VSize, result.Fees = true, t.vsize, &rpc.MempoolFees{Base: toBTC(t.fee)}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// output 返回有效交易的输出
+func (s *Server) output(op outpoint) (output, bool) {
+	t, ok := s.txs[op.txid]
+	if !ok || !s.valid(t) || op.vout < 0 || op.vout >= len(t.outputs) {
+		return output{}, false
+	}
+	return t.outputs[op.vout], true
+}
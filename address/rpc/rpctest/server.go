@@ -0,0 +1,613 @@
+// Package rpctest 提供进程内的模拟 BitcoinPoW 节点，用于离线测试各命令。
+// 节点状态（区块、交易池、钱包）保存在内存中，测试可以直接修改和检查
+package rpctest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"address/rpc"
+	"address/zmq"
+)
+
+// 模拟节点接受的RPC用户名和密码
+const (
+	Username = "rpctest"
+	Password = "rpctest"
+)
+
+const (
+	genesisTime  = 1700000000
+	blockSpacing = 600
+	coin         = 1e8
+	// dustLimit 低于此值的找零并入手续费（聪）
+	dustLimit = 546
+	// 默认的交易池链长度限制，与 bitcoind 的 limitancestorcount/limitancestorsize 一致
+	defaultAncestorLimit     = 25
+	defaultAncestorSizeLimit = 101000
+)
+
+// Handler 处理一个RPC请求，wallet 为请求路径中的钱包名，返回 *rpc.Error 时作为RPC错误返回
+type Handler func(wallet string, params []json.RawMessage) (interface{}, error)
+
+// Server 是模拟节点
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	blocks     []*block
+	txs        map[string]*tx
+	mempool    map[string]bool
+	wallets    map[string]*wallet
+	owners     map[string]*wallet // 地址 -> 所属钱包
+	handlers   map[string]Handler
+	overrides  map[string]Handler
+	calls      map[string]int
+	priorities map[string]float64
+	locked     map[outpoint]bool // lockunspent 锁定的输出，不区分钱包
+	hashPS     float64
+	defaultFee int64 // 未指定费率时使用的费率（sat/vB）
+	smartFee   int64 // estimatesmartfee 返回的费率（sat/vB），0 表示数据不足
+	counter    uint64
+	publisher  *zmq.Publisher    // ZMQ 调用后才启动
+	sequences  map[string]uint32 // 各通知主题的序号
+}
+
+type block struct {
+	hash   string
+	height int64
+	time   int64
+	txids  []string
+}
+
+type outpoint struct {
+	txid string
+	vout int
+}
+
+type output struct {
+	address string
+	amount  int64 // 聪
+}
+
+type tx struct {
+	txid        string
+	wallet      string // 发送方钱包，外部注资的交易为空
+	inputs      []outpoint
+	outputs     []output
+	fee         int64
+	vsize       int
+	height      int64 // 未确认时为 -1
+	replaceable bool
+	replacedBy  string
+	replaces    string
+	time        int64
+	comment     string // sendmany 的 comment，bumpfee 时复制到替换交易
+}
+
+type wallet struct {
+	name      string
+	addresses []string
+	labels    map[string]string
+	change    map[string]bool
+}
+
+// NewServer 启动只有创世区块的模拟节点，测试结束时需调用 Close
+func NewServer() *Server {
+	s := &Server{
+		txs:        make(map[string]*tx),
+		mempool:    make(map[string]bool),
+		wallets:    make(map[string]*wallet),
+		owners:     make(map[string]*wallet),
+		overrides:  make(map[string]Handler),
+		calls:      make(map[string]int),
+		priorities: make(map[string]float64),
+		locked:     make(map[outpoint]bool),
+		hashPS:     1.5e12,
+		defaultFee: 1,
+	}
+	s.handlers = map[string]Handler{
+		"listwallets":                  s.listWallets,
+		"createwallet":                 s.createWallet,
+		"getnewaddress":                s.getNewAddress,
+		"listreceivedbyaddress":        s.listReceivedByAddress,
+		"getbalances":                  s.getBalances,
+		"listunspent":                  s.listUnspent,
+		"gettransaction":               s.getTransaction,
+		"sendmany":                     s.sendMany,
+		"bumpfee":                      s.bumpFee,
+		"getblockcount":                s.getBlockCount,
+		"getblockhash":                 s.getBlockHash,
+		"getblockheader":               s.getBlockHeader,
+		"getnetworkhashps":             s.getNetworkHashPS,
+		"prioritisetransaction":        s.prioritiseTransaction,
+		"generate":                     s.generate,
+		"getrawmempool":                s.getRawMempool,
+		"getmempoolentry":              s.getMempoolEntry,
+		"getmempoolancestors":          s.getMempoolAncestors,
+		"getrawchangeaddress":          s.getRawChangeAddress,
+		"createrawtransaction":         s.createRawTransaction,
+		"signrawtransactionwithwallet": s.signRawTransactionWithWallet,
+		"sendrawtransaction":           s.sendRawTransaction,
+		"fundrawtransaction":           s.fundRawTransaction,
+		"decoderawtransaction":         s.decodeRawTransaction,
+		"walletcreatefundedpsbt":       s.walletCreateFundedPSBT,
+		"walletprocesspsbt":            s.walletProcessPSBT,
+		"finalizepsbt":                 s.finalizePSBT,
+		"decodepsbt":                   s.decodePSBT,
+		"analyzepsbt":                  s.analyzePSBT,
+		"testmempoolaccept":            s.testMempoolAccept,
+		"estimatesmartfee":             s.estimateSmartFee,
+		"validateaddress":              s.validateAddress,
+		"listtransactions":             s.listTransactions,
+		"lockunspent":                  s.lockUnspent,
+		"listlockunspent":              s.listLockUnspent,
+		"listsinceblock":               s.listSinceBlock,
+	}
+	s.mine(1, false)
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Auth 返回连接模拟节点所用的认证配置
+func (s *Server) Auth() rpc.Auth {
+	return rpc.Auth{Username: Username, Password: Password}
+}
+
+// Client 返回连接模拟节点的客户端
+func (s *Server) Client(opts ...rpc.Option) *rpc.Client {
+	return rpc.NewClient(s.URL, append([]rpc.Option{rpc.WithBasicAuth(Username, Password)}, opts...)...)
+}
+
+// Handle 用 h 替换方法 method 的实现，可用于注入错误，h 为 nil 时恢复默认实现
+func (s *Server) Handle(method string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if h == nil {
+		delete(s.overrides, method)
+		return
+	}
+	s.overrides[method] = h
+}
+
+// Calls 返回方法 method 被调用的次数，批量请求中的每一项单独计数
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// SetNetworkHashPS 设置 getnetworkhashps 的返回值
+func (s *Server) SetNetworkHashPS(hashps float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hashPS = hashps
+}
+
+// SetDefaultFeeRate 设置 sendmany 未指定 fee_rate 时的费率（sat/vB）
+func (s *Server) SetDefaultFeeRate(satPerVByte int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaultFee = satPerVByte
+}
+
+// SetSmartFee 设置 estimatesmartfee 返回的费率（sat/vB），0 表示数据不足
+func (s *Server) SetSmartFee(satPerVByte int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.smartFee = satPerVByte
+}
+
+// Prioritised 返回 prioritisetransaction 对 txid 累计的 fee_delta
+func (s *Server) Prioritised(txid string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.priorities[txid]
+}
+
+// CreateWallet 创建并加载钱包
+func (s *Server) CreateWallet(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addWallet(name)
+}
+
+// NewAddress 在钱包中生成新地址
+func (s *Server) NewAddress(walletName, label string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.newAddress(s.wallets[walletName], label, false)
+}
+
+// Fund 从外部向钱包的新地址转入 amount BTCW，交易留在交易池中，调用 Mine 确认
+func (s *Server) Fund(walletName string, amount float64) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	address := s.newAddress(s.wallets[walletName], "", false)
+	t := &tx{
+		txid:    s.newID(),
+		outputs: []output{{address: address, amount: toSat(amount)}},
+		vsize:   txVSize(1, 1),
+		height:  -1,
+		time:    s.tip().time,
+	}
+	t.fee = int64(t.vsize)
+	s.addTx(t)
+	return t.txid
+}
+
+// Mine 产生 n 个区块，第一个区块打包交易池中的全部交易，返回新区块的哈希
+func (s *Server) Mine(n int) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mine(n, true)
+}
+
+// MineEmpty 产生 n 个不打包任何交易的区块，用于模拟交易长时间未确认
+func (s *Server) MineEmpty(n int) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mine(n, false)
+}
+
+// Height 返回当前区块高度
+func (s *Server) Height() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tip().height
+}
+
+// Mempool 返回交易池中的交易，按 txid 排序
+func (s *Server) Mempool() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	txids := make([]string, 0, len(s.mempool))
+	for txid := range s.mempool {
+		txids = append(txids, txid)
+	}
+	sort.Strings(txids)
+	return txids
+}
+
+// Tx 是交易的快照
+type Tx struct {
+	TxID          string
+	Wallet        string
+	Fee           int64 // 聪
+	VSize         int
+	Confirmations int64
+	Replaceable   bool
+	ReplacedBy    string
+	Replaces      string
+	Outputs       map[string]float64 // 地址 -> BTCW
+}
+
+// FeeRate 返回交易费率（sat/vB）
+func (t Tx) FeeRate() float64 {
+	return float64(t.Fee) / float64(t.VSize)
+}
+
+// Tx 返回交易的快照，交易不存在时 ok 为 false
+func (s *Server) Tx(txid string) (Tx, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.txs[txid]
+	if !ok {
+		return Tx{}, false
+	}
+	outputs := make(map[string]float64, len(t.outputs))
+	for _, o := range t.outputs {
+		outputs[o.address] += toBTC(o.amount)
+	}
+	return Tx{
+		TxID:          t.txid,
+		Wallet:        t.wallet,
+		Fee:           t.fee,
+		VSize:         t.vsize,
+		Confirmations: s.confirmations(t),
+		Replaceable:   t.replaceable,
+		ReplacedBy:    t.replacedBy,
+		Replaces:      t.replaces,
+		Outputs:       outputs,
+	}, true
+}
+
+// Received 返回有效交易（已确认或在交易池中）付给 address 的总额
+func (s *Server) Received(address string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var total int64
+	for _, t := range s.txs {
+		if !s.valid(t) {
+			continue
+		}
+		for _, o := range t.outputs {
+			if o.address == address {
+				total += o.amount
+			}
+		}
+	}
+	return toBTC(total)
+}
+
+type request struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type response struct {
+	Result interface{}     `json:"result"`
+	Error  *rpc.Error      `json:"error"`
+	ID     json.RawMessage `json:"id"`
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	username, password, ok := r.BasicAuth()
+	if !ok || username != Username || password != Password {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	walletName := ""
+	if rest, ok := strings.CutPrefix(r.URL.Path, "/wallet/"); ok {
+		name, err := url.PathUnescape(rest)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		walletName = name
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	// 批量请求总是返回 200，错误在每一项中
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var reqs []request
+		if err := json.Unmarshal(trimmed, &reqs); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resps := make([]response, len(reqs))
+		for i, req := range reqs {
+			resps[i] = s.dispatch(walletName, req)
+		}
+		json.NewEncoder(w).Encode(resps)
+		return
+	}
+
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := s.dispatch(walletName, req)
+	switch {
+	case resp.Error == nil:
+	case resp.Error.Code == rpc.ErrCodeMethodNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) dispatch(walletName string, req request) response {
+	s.mu.Lock()
+	s.calls[req.Method]++
+	h, ok := s.overrides[req.Method]
+	if !ok {
+		h, ok = s.handlers[req.Method]
+	}
+	s.mu.Unlock()
+
+	resp := response{ID: req.ID}
+	if !ok {
+		resp.Error = &rpc.Error{Code: rpc.ErrCodeMethodNotFound, Message: "Method not found"}
+		return resp
+	}
+	result, err := h(walletName, req.Params)
+	if err != nil {
+		rpcErr, ok := err.(*rpc.Error)
+		if !ok {
+			rpcErr = &rpc.Error{Code: rpc.ErrCodeMisc, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+		return resp
+	}
+	resp.Result = result
+	return resp
+}
+
+// rpcError 构造RPC错误
+func rpcError(code int, format string, args ...interface{}) *rpc.Error {
+	return &rpc.Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// arg 把第 i 个参数解码到 v，参数缺失或为 null 时保留 v 的默认值
+func arg(params []json.RawMessage, i int, v interface{}) error {
+	if i >= len(params) || string(params[i]) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params[i], v); err != nil {
+		return rpcError(rpc.ErrCodeTypeError, "JSON value of parameter %d is not of expected type: %v", i+1, err)
+	}
+	return nil
+}
+
+// args 依次解码参数
+func args(params []json.RawMessage, vs ...interface{}) error {
+	for i, v := range vs {
+		if err := arg(params, i, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) newID() string {
+	s.counter++
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], s.counter)
+	sum := sha256.Sum256(b[:])
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Server) tip() *block {
+	return s.blocks[len(s.blocks)-1]
+}
+
+func (s *Server) mine(n int, includeMempool bool) []string {
+	hashes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		b := &block{hash: s.newID(), height: int64(len(s.blocks)), time: genesisTime + int64(len(s.blocks))*blockSpacing}
+		if includeMempool && i == 0 {
+			for txid := range s.mempool {
+				b.txids = append(b.txids, txid)
+				s.txs[txid].height = b.height
+			}
+			sort.Strings(b.txids)
+			s.mempool = make(map[string]bool)
+		}
+		s.blocks = append(s.blocks, b)
+		hashes = append(hashes, b.hash)
+		s.notifyBlock(b)
+	}
+	return hashes
+}
+
+func (s *Server) addTx(t *tx) {
+	s.txs[t.txid] = t
+	s.mempool[t.txid] = true
+	s.notifyTx(t)
+}
+
+// valid 返回交易是否已确认或在交易池中
+func (s *Server) valid(t *tx) bool {
+	return t.height >= 0 || s.mempool[t.txid]
+}
+
+func (s *Server) confirmations(t *tx) int64 {
+	if t.height >= 0 {
+		return s.tip().height - t.height + 1
+	}
+	if t.replacedBy != "" {
+		// 被替换的交易，替换交易确认后为负数
+		if r := s.txs[t.replacedBy]; r != nil && r.height >= 0 {
+			return -s.confirmations(r)
+		}
+	}
+	return 0
+}
+
+// spender 返回花费了 op 的有效交易
+func (s *Server) spender(op outpoint) *tx {
+	for txid := range s.mempool {
+		for _, in := range s.txs[txid].inputs {
+			if in == op {
+				return s.txs[txid]
+			}
+		}
+	}
+	for _, t := range s.txs {
+		if t.height < 0 {
+			continue
+		}
+		for _, in := range t.inputs {
+			if in == op {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// ancestors 返回交易池中 t 的全部祖先交易
+func (s *Server) ancestors(t *tx) map[string]*tx {
+	found := make(map[string]*tx)
+	var walk func(t *tx)
+	walk = func(t *tx) {
+		for _, in := range t.inputs {
+			parent := s.txs[in.txid]
+			if parent == nil || !s.mempool[parent.txid] || found[parent.txid] != nil {
+				continue
+			}
+			found[parent.txid] = parent
+			walk(parent)
+		}
+	}
+	walk(t)
+	return found
+}
+
+// descendants 返回交易池中花费 t 输出的全部后代交易
+func (s *Server) descendants(t *tx) map[string]*tx {
+	found := make(map[string]*tx)
+	var walk func(t *tx)
+	walk = func(t *tx) {
+		for txid := range s.mempool {
+			child := s.txs[txid]
+			if found[txid] != nil {
+				continue
+			}
+			for _, in := range child.inputs {
+				if in.txid == t.txid {
+					found[txid] = child
+					walk(child)
+					break
+				}
+			}
+		}
+	}
+	walk(t)
+	return found
+}
+
+// checkChainLimits 检查 t 加入交易池后是否超过祖先数量和大小限制
+func (s *Server) checkChainLimits(t *tx) error {
+	ancestors := s.ancestors(t)
+	size := t.vsize
+	for _, a := range ancestors {
+		size += a.vsize
+	}
+	if len(ancestors)+1 > defaultAncestorLimit {
+		return rpcError(rpc.ErrCodeVerifyRejected, "too-long-mempool-chain, too many unconfirmed ancestors [limit: %d]", defaultAncestorLimit)
+	}
+	if size > defaultAncestorSizeLimit {
+		return rpcError(rpc.ErrCodeVerifyRejected, "too-long-mempool-chain, exceeds ancestor size limit [limit: %d]", defaultAncestorSizeLimit)
+	}
+	return nil
+}
+
+// p2wpkhScript 是模拟钱包所有地址的 scriptPubKey，交易大小都按 P2WPKH 计算
+var p2wpkhScript = "0014" + strings.Repeat("00", 20)
+
+// txVSize 估算 P2WPKH 交易的虚拟大小
+func txVSize(inputs, outputs int) int {
+	return 11 + 68*inputs + 31*outputs
+}
+
+func toSat(amount float64) int64 {
+	if amount < 0 {
+		return -toSat(-amount)
+	}
+	return int64(amount*coin + 0.5)
+}
+
+func toBTC(sat int64) float64 {
+	return float64(sat) / coin
+}
@@ -0,0 +1,261 @@
+package rpctest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"address/rpc"
+)
+
+func TestWalletRouting(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	client := s.Client()
+
+	if _, err := client.GetBalances(ctx); !rpc.IsCode(err, rpc.ErrCodeWalletNotFound) {
+		t.Fatalf("no wallet loaded: got %v", err)
+	}
+	s.CreateWallet("w1")
+	if _, err := client.GetBalances(ctx); err != nil {
+		t.Fatalf("single wallet without path: %v", err)
+	}
+	s.CreateWallet("w2")
+	if _, err := client.GetBalances(ctx); !rpc.IsCode(err, rpc.ErrCodeWalletNotSpecified) {
+		t.Fatalf("two wallets without path: got %v", err)
+	}
+	if _, err := client.Wallet("w3").GetBalances(ctx); !rpc.IsCode(err, rpc.ErrCodeWalletNotFound) {
+		t.Fatalf("unknown wallet: got %v", err)
+	}
+	if err := client.Call(ctx, "nosuchmethod", nil); !rpc.IsCode(err, rpc.ErrCodeMethodNotFound) {
+		t.Fatalf("unknown method: got %v", err)
+	}
+
+	var httpErr *rpc.HTTPError
+	bad := rpc.NewClient(s.URL, rpc.WithBasicAuth("user", "wrong"))
+	if _, err := bad.ListWallets(ctx); err == nil || !errors.As(err, &httpErr) || httpErr.StatusCode != 401 {
+		t.Fatalf("wrong password: got %v", err)
+	}
+}
+
+func TestSendManyAndBumpFee(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	s.CreateWallet("payer")
+	s.CreateWallet("payee")
+	s.Fund("payer", 1)
+	s.Mine(1)
+	to := s.NewAddress("payee", "a")
+	payer := s.Client().Wallet("payer")
+
+	sent, err := payer.SendMany(ctx, map[string]float64{to: 0.1}, rpc.SendManyOptions{Minconf: 1, FeeRate: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, ok := s.Tx(sent.TxID)
+	if !ok || tx.FeeRate() != 10 || tx.Outputs[to] != 0.1 {
+		t.Fatalf("unexpected tx %+v", tx)
+	}
+	gt, err := payer.GetTransaction(ctx, sent.TxID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gt.Hex)/2 != tx.VSize || gt.Fee != -float64(tx.Fee)/1e8 || gt.BIP125Replaceable != "yes" {
+		t.Fatalf("unexpected gettransaction %+v", gt)
+	}
+
+	if _, err := payer.BumpFee(ctx, sent.TxID, &rpc.BumpFeeOptions{FeeRate: 10.5}); !rpc.IsCode(err, rpc.ErrCodeInvalidParameter) {
+		t.Fatalf("bump below incremental fee: got %v", err)
+	}
+	bumped, err := payer.BumpFee(ctx, sent.TxID, &rpc.BumpFeeOptions{FeeRate: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mempool := s.Mempool(); len(mempool) != 1 || mempool[0] != bumped.TxID {
+		t.Fatalf("mempool %v, want only %s", mempool, bumped.TxID)
+	}
+	if old, _ := s.Tx(sent.TxID); old.ReplacedBy != bumped.TxID {
+		t.Fatalf("old tx not replaced: %+v", old)
+	}
+	if got := s.Received(to); got != 0.1 {
+		t.Fatalf("payee received %v", got)
+	}
+
+	s.Mine(1)
+	balances, err := s.Client().Wallet("payee").GetBalances(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances.Mine.Trusted != 0.1 {
+		t.Fatalf("payee balance %+v", balances.Mine)
+	}
+	if _, err := payer.BumpFee(ctx, bumped.TxID, nil); !rpc.IsCode(err, rpc.ErrCodeWallet) {
+		t.Fatalf("bump confirmed tx: got %v", err)
+	}
+}
+
+func TestBatchAndChain(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	s.MineEmpty(3)
+	client := s.Client()
+
+	batch := client.NewBatch()
+	for h := int64(0); h <= 4; h++ {
+		batch.Add("getblockhash", new(string), h)
+	}
+	if err := batch.Send(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if batch.Errors() != 1 || !rpc.IsCode(batch.Calls()[4].Err, rpc.ErrCodeInvalidParameter) {
+		t.Fatalf("want only height 4 out of range, got %d errors", batch.Errors())
+	}
+	header, err := client.GetBlockHeader(ctx, *batch.Calls()[3].Result.(*string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Height != 3 || header.PreviousBlockHash != *batch.Calls()[2].Result.(*string) {
+		t.Fatalf("unexpected header %+v", header)
+	}
+	if s.Calls("getblockhash") != 5 {
+		t.Fatalf("getblockhash calls %d", s.Calls("getblockhash"))
+	}
+}
+
+func TestFeeEstimation(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	client := s.Client()
+
+	fee, err := client.EstimateSmartFee(ctx, 2, "")
+	if err != nil || fee.FeeRate != 0 || len(fee.Errors) == 0 {
+		t.Fatalf("estimatesmartfee without data = %+v, %v", fee, err)
+	}
+	if _, err := client.EstimateSmartFee(ctx, 2, "fast"); !rpc.IsCode(err, rpc.ErrCodeInvalidParameter) {
+		t.Fatalf("want invalid estimate_mode error, got %v", err)
+	}
+	s.SetSmartFee(15)
+	if fee, err := client.EstimateSmartFee(ctx, 6, "ECONOMICAL"); err != nil || fee.FeeRate != 0.00015 || fee.Blocks != 6 {
+		t.Fatalf("estimatesmartfee = %+v, %v", fee, err)
+	}
+
+	s.CreateWallet("w")
+	funding := s.Fund("w", 1)
+	entries, err := client.GetRawMempoolVerbose(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[funding].VSize == 0 {
+		t.Fatalf("unexpected verbose mempool %+v", entries)
+	}
+}
+
+func TestFundRawTransaction(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	s.CreateWallet("payer")
+	s.CreateWallet("payee")
+	s.Fund("payer", 1)
+	s.Fund("payer", 0.00001)
+	s.Mine(1)
+	to := s.NewAddress("payee", "a")
+	payer := s.Client().Wallet("payer")
+
+	unspent, err := payer.ListUnspent(ctx, 1, 9999999, nil, false, &rpc.ListUnspentOptions{MinimumAmount: 0.1})
+	if err != nil || len(unspent) != 1 {
+		t.Fatalf("listunspent = %+v, %v", unspent, err)
+	}
+	raw, err := payer.CreateRawTransaction(ctx, []rpc.TxInput{{TxID: unspent[0].TxID, Vout: unspent[0].Vout}}, map[string]float64{to: 0.5}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addInputs := false
+	funded, err := payer.FundRawTransaction(ctx, raw, &rpc.FundRawTransactionOptions{AddInputs: &addInputs, FeeRate: 5})
+	if err != nil || funded.ChangePos != 1 || funded.Fee != 5*float64(txVSize(1, 2))/1e8 {
+		t.Fatalf("fundrawtransaction = %+v, %v", funded, err)
+	}
+	signed, err := payer.SignRawTransactionWithWallet(ctx, funded.Hex)
+	if err != nil || !signed.Complete {
+		t.Fatalf("signrawtransactionwithwallet = %+v, %v", signed, err)
+	}
+	decoded, err := payer.DecodeRawTransaction(ctx, signed.Hex)
+	if err != nil || len(decoded.Vin) != 1 || decoded.Vin[0].TxID != unspent[0].TxID || len(decoded.Vout) != 2 {
+		t.Fatalf("decoderawtransaction = %+v, %v", decoded, err)
+	}
+	for i := 0; i < 2; i++ {
+		txid, err := payer.SendRawTransaction(ctx, signed.Hex, nil)
+		if err != nil || txid != decoded.TxID {
+			t.Fatalf("sendrawtransaction #%d = %s, %v, want %s", i+1, txid, err, decoded.TxID)
+		}
+	}
+	if tx, ok := s.Tx(decoded.TxID); !ok || tx.FeeRate() != 5 || tx.Outputs[to] != 0.5 {
+		t.Fatalf("unexpected tx %+v", tx)
+	}
+	s.Mine(1)
+	if _, err := payer.SendRawTransaction(ctx, signed.Hex, nil); !rpc.IsCode(err, rpc.ErrCodeVerifyAlreadyInChain) {
+		t.Fatalf("rebroadcast confirmed tx: got %v", err)
+	}
+
+	// 只用预选的输入，不足时不从其它 UTXO 补充
+	unspent, _ = payer.ListUnspent(ctx, 1, 9999999, nil, false, &rpc.ListUnspentOptions{MinimumAmount: 0.1})
+	raw, _ = payer.CreateRawTransaction(ctx, []rpc.TxInput{{TxID: unspent[0].TxID, Vout: unspent[0].Vout}}, map[string]float64{to: unspent[0].Amount}, true)
+	if _, err := payer.FundRawTransaction(ctx, raw, &rpc.FundRawTransactionOptions{AddInputs: &addInputs}); !rpc.IsCode(err, rpc.ErrCodeInsufficientFunds) {
+		t.Fatalf("insufficient pre-selected inputs: got %v", err)
+	}
+}
+
+func TestTestMempoolAccept(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	s.CreateWallet("payer")
+	s.CreateWallet("payee")
+	s.Fund("payer", 1)
+	s.Mine(1)
+	to := s.NewAddress("payee", "")
+	payer := s.Client().Wallet("payer")
+
+	funded, err := payer.WalletCreateFundedPSBT(ctx, nil, map[string]float64{to: 0.5}, &rpc.FundRawTransactionOptions{FeeRate: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	analyzed, err := s.Client().AnalyzePSBT(ctx, funded.PSBT)
+	if err != nil || analyzed.Next != "signer" || analyzed.EstimatedVSize != txVSize(1, 2) || analyzed.Fee != funded.Fee {
+		t.Fatalf("analyzepsbt = %+v, %v", analyzed, err)
+	}
+	decoded, err := s.Client().DecodePSBT(ctx, funded.PSBT)
+	if err != nil || len(decoded.Inputs) != 1 || decoded.Inputs[0].WitnessUTXO.Amount != 1 || decoded.Fee != funded.Fee || len(decoded.Tx.Vout) != 2 {
+		t.Fatalf("decodepsbt = %+v, %v", decoded, err)
+	}
+
+	// 未签名的交易被拒绝
+	unsigned, err := parsePSBT(funded.PSBT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := s.Client().TestMempoolAccept(ctx, []string{unsigned.encode()}, nil)
+	if err != nil || len(results) != 1 || results[0].Allowed || results[0].RejectReason == "" {
+		t.Fatalf("testmempoolaccept unsigned = %+v, %v", results, err)
+	}
+
+	processed, err := payer.WalletProcessPSBT(ctx, funded.PSBT, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	final, err := s.Client().FinalizePSBT(ctx, processed.PSBT)
+	if err != nil || !final.Complete {
+		t.Fatalf("finalizepsbt = %+v, %v", final, err)
+	}
+	results, err = s.Client().TestMempoolAccept(ctx, []string{final.Hex}, nil)
+	if err != nil || len(results) != 1 || !results[0].Allowed || results[0].TxID != decoded.Tx.TxID || results[0].Fees.Base != funded.Fee {
+		t.Fatalf("testmempoolaccept = %+v, %v", results, err)
+	}
+	if mempool := s.Mempool(); len(mempool) != 0 {
+		t.Errorf("testmempoolaccept added %v to mempool", mempool)
+	}
+}
@@ -0,0 +1,760 @@
+package rpctest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"address/rpc"
+)
+
+// maxTxFee 对应 bitcoind 的 -maxtxfee 默认值（聪）
+const maxTxFee = 10000000
+
+// maxStandardTxVSize 对应 bitcoind 的 MAX_STANDARD_TX_WEIGHT（400000 WU）
+const maxStandardTxVSize = 100000
+
+func (s *Server) addWallet(name string) *wallet {
+	w := &wallet{name: name, labels: make(map[string]string), change: make(map[string]bool)}
+	s.wallets[name] = w
+	return w
+}
+
+func (s *Server) newAddress(w *wallet, label string, change bool) string {
+	s.counter++
+	address := fmt.Sprintf("bpw1q%034x", s.counter)
+	s.owners[address] = w
+	if change {
+		w.change[address] = true
+	} else {
+		w.addresses = append(w.addresses, address)
+		w.labels[address] = label
+	}
+	return address
+}
+
+// wallet 按请求路径选择钱包，路径中没有钱包名时只在节点仅加载一个钱包时可用
+func (s *Server) wallet(name string) (*wallet, error) {
+	if name != "" {
+		w, ok := s.wallets[name]
+		if !ok {
+			return nil, rpcError(rpc.ErrCodeWalletNotFound, "Requested wallet does not exist or is not loaded")
+		}
+		return w, nil
+	}
+	switch len(s.wallets) {
+	case 0:
+		return nil, rpcError(rpc.ErrCodeWalletNotFound, "No wallet is loaded. Load a wallet using loadwallet or create a new one with createwallet. (Note: A default wallet is no longer automatically created)")
+	case 1:
+		for _, w := range s.wallets {
+			return w, nil
+		}
+	}
+	return nil, rpcError(rpc.ErrCodeWalletNotSpecified, "Wallet file not specified (must request wallet RPC through /wallet/<filename> uri-path).")
+}
+
+// walletCoin 是钱包中的一个 UTXO
+type walletCoin struct {
+	outpoint
+	output
+	tx *tx
+}
+
+// coins 返回钱包中未花费的输出，按确认数从多到少排序
+func (s *Server) coins(w *wallet) []walletCoin {
+	var coins []walletCoin
+	for _, t := range s.txs {
+		if !s.valid(t) {
+			continue
+		}
+		for vout, o := range t.outputs {
+			if s.owners[o.address] != w {
+				continue
+			}
+			op := outpoint{txid: t.txid, vout: vout}
+			if s.spender(op) != nil {
+				continue
+			}
+			coins = append(coins, walletCoin{outpoint: op, output: o, tx: t})
+		}
+	}
+	sort.Slice(coins, func(i, j int) bool {
+		ci, cj := s.confirmations(coins[i].tx), s.confirmations(coins[j].tx)
+		if ci != cj {
+			return ci > cj
+		}
+		if coins[i].txid != coins[j].txid {
+			return coins[i].txid < coins[j].txid
+		}
+		return coins[i].vout < coins[j].vout
+	})
+	return coins
+}
+
+// trusted 返回未确认的 c 是否可以信任，即由本钱包发出
+func (s *Server) trusted(w *wallet, c walletCoin) bool {
+	return s.confirmations(c.tx) > 0 || c.tx.wallet == w.name
+}
+
+func (s *Server) listWallets(_ string, _ []json.RawMessage) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.wallets))
+	for name := range s.wallets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *Server) createWallet(_ string, params []json.RawMessage) (interface{}, error) {
+	var name string
+	if err := arg(params, 0, &name); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.wallets[name]; ok {
+		return nil, rpcError(rpc.ErrCodeWallet, "Wallet file verification failed. Failed to create database path '%s'. Database already exists.", name)
+	}
+	s.addWallet(name)
+	return rpc.CreateWalletResult{Name: name}, nil
+}
+
+func (s *Server) getNewAddress(walletName string, params []json.RawMessage) (interface{}, error) {
+	var label string
+	if err := arg(params, 0, &label); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := s.wallet(walletName)
+	if err != nil {
+		return nil, err
+	}
+	return s.newAddress(w, label, false), nil
+}
+
+func (s *Server) getRawChangeAddress(walletName string, _ []json.RawMessage) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := s.wallet(walletName)
+	if err != nil {
+		return nil, err
+	}
+	return s.newAddress(w, "", true), nil
+}
+
+func (s *Server) listReceivedByAddress(walletName string, params []json.RawMessage) (interface{}, error) {
+	minconf, includeEmpty := int64(1), false
+	if err := args(params, &minconf, &includeEmpty); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := s.wallet(walletName)
+	if err != nil {
+		return nil, err
+	}
+
+	received := make([]rpc.ReceivedByAddress, 0, len(w.addresses))
+	for _, address := range w.addresses {
+		item := rpc.ReceivedByAddress{Address: address, Label: w.labels[address], Txids: []string{}}
+		var amount int64
+		minConfs := int64(math.MaxInt64)
+		for _, t := range s.txs {
+			confs := s.confirmations(t)
+			if !s.valid(t) || confs < minconf {
+				continue
+			}
+			paid := false
+			for _, o := range t.outputs {
+				if o.address == address {
+					amount += o.amount
+					paid = true
+				}
+			}
+			if paid {
+				item.Txids = append(item.Txids, t.txid)
+				if confs < minConfs {
+					minConfs = confs
+				}
+			}
+		}
+		if amount == 0 && !includeEmpty {
+			continue
+		}
+		sort.Strings(item.Txids)
+		item.Amount = toBTC(amount)
+		if len(item.Txids) > 0 {
+			item.Confirmations = int(minConfs)
+		}
+		received = append(received, item)
+	}
+	return received, nil
+}
+
+func (s *Server) getBalances(walletName string, _ []json.RawMessage) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := s.wallet(walletName)
+	if err != nil {
+		return nil, err
+	}
+	var trusted, pending int64
+	for _, c := range s.coins(w) {
+		if s.trusted(w, c) {
+			trusted += c.amount
+		} else {
+			pending += c.amount
+		}
+	}
+	return rpc.Balances{Mine: rpc.Balance{Trusted: toBTC(trusted), UntrustedPending: toBTC(pending)}}, nil
+}
+
+func (s *Server) listUnspent(walletName string, params []json.RawMessage) (interface{}, error) {
+	minconf, maxconf := int64(1), int64(9999999)
+	var addresses []string
+	includeUnsafe := true
+	var opts rpc.ListUnspentOptions
+	if err := args(params, &minconf, &maxconf, &addresses, &includeUnsafe, &opts); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := s.wallet(walletName)
+	if err != nil {
+		return nil, err
+	}
+
+	unspent := []rpc.Unspent{}
+	var sum int64
+	for _, c := range s.coins(w) {
+		confs := s.confirmations(c.tx)
+		safe := s.trusted(w, c)
+		switch {
+		case confs < minconf || confs > maxconf || s.locked[c.outpoint]:
+			continue
+		case !safe && !includeUnsafe:
+			continue
+		case len(addresses) > 0 && !contains(addresses, c.address):
+			continue
+		case opts.MinimumAmount > 0 && c.amount < toSat(opts.MinimumAmount):
+			continue
+		case opts.MaximumAmount > 0 && c.amount > toSat(opts.MaximumAmount):
+			continue
+		}
+		u := rpc.Unspent{
+			TxID:          c.txid,
+			Vout:          uint32(c.vout),
+			Address:       c.address,
+			Label:         w.labels[c.address],
+			ScriptPubKey:  p2wpkhScript,
+			Amount:        toBTC(c.amount),
+			Confirmations: confs,
+			Spendable:     true,
+			Solvable:      true,
+			Safe:          safe,
+		}
+		if confs == 0 {
+			ancestors := s.ancestors(c.tx)
+			u.AncestorCount, u.AncestorSize, u.AncestorFees = 1, c.tx.vsize, c.tx.fee
+			for _, a := range ancestors {
+				u.AncestorCount++
+				u.AncestorSize += a.vsize
+				u.AncestorFees += a.fee
+			}
+		}
+		unspent = append(unspent, u)
+		sum += c.amount
+		if opts.MaximumCount > 0 && len(unspent) >= opts.MaximumCount {
+			break
+		}
+		if opts.MinimumSumAmount > 0 && sum >= toSat(opts.MinimumSumAmount) {
+			break
+		}
+	}
+	return unspent, nil
+}
+
+func (s *Server) getTransaction(walletName string, params []json.RawMessage) (interface{}, error) {
+	var txid string
+	if err := arg(params, 0, &txid); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := s.wallet(walletName)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := s.txs[txid]
+	if !ok || !s.involves(w, t) {
+		return nil, rpcError(rpc.ErrCodeInvalidAddress, "Invalid or non-wallet transaction id")
+	}
+
+	result := rpc.Transaction{
+		TxID:            t.txid,
+		Confirmations:   s.confirmations(t),
+		WalletConflicts: []string{},
+		ReplacedByTxID:  t.replacedBy,
+		ReplacesTxID:    t.replaces,
+		Time:            t.time,
+		TimeReceived:    t.time,
+		Details:         []rpc.TransactionDetail{},
+		Hex:             strings.Repeat("00", t.vsize),
+	}
+	result.BIP125Replaceable = "no"
+	if t.replaceable && result.Confirmations == 0 {
+		result.BIP125Replaceable = "yes"
+	}
+	if t.height >= 0 {
+		b := s.blocks[t.height]
+		result.BlockHash, result.BlockHeight = b.hash, b.height
+	}
+	if t.replacedBy != "" {
+		result.WalletConflicts = append(result.WalletConflicts, t.replacedBy)
+	}
+	if t.replaces != "" {
+		result.WalletConflicts = append(result.WalletConflicts, t.replaces)
+	}
+
+	var amount int64
+	for vout, o := range t.outputs {
+		mine := s.owners[o.address] == w
+		switch {
+		case t.wallet == w.name && !mine:
+			amount -= o.amount
+			result.Details = append(result.Details, rpc.TransactionDetail{
+				Address: o.address, Category: "send", Amount: -toBTC(o.amount), Vout: uint32(vout), Fee: -toBTC(t.fee),
+			})
+		case t.wallet != w.name && mine:
+			amount += o.amount
+			result.Details = append(result.Details, rpc.TransactionDetail{
+				Address: o.address, Category: "receive", Amount: toBTC(o.amount), Label: w.labels[o.address], Vout: uint32(vout),
+			})
+		}
+	}
+	result.Amount = toBTC(amount)
+	if t.wallet == w.name {
+		result.Fee = -toBTC(t.fee)
+	}
+	return result, nil
+}
+
+func (s *Server) listTransactions(walletName string, params []json.RawMessage) (interface{}, error) {
+	label, count, skip := "*", 10, 0
+	if err := args(params, &label, &count, &skip); err != nil {
+		return nil, err
+	}
+	if count < 0 || skip < 0 {
+		return nil, rpcError(rpc.ErrCodeInvalidParameter, "Negative count or from")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := s.wallet(walletName)
+	if err != nil {
+		return nil, err
+	}
+	entries := s.walletTransactions(w, label, func(*tx) bool { return true })
+	end := max(len(entries)-skip, 0)
+	return entries[max(end-count, 0):end], nil
+}
+
+// listSinceBlock 返回 blockhash 之后确认的和未确认的钱包交易，模拟链没有重组，removed 总是为空
+func (s *Server) listSinceBlock(walletName string, params []json.RawMessage) (interface{}, error) {
+	var blockHash string
+	if err := arg(params, 0, &blockHash); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := s.wallet(walletName)
+	if err != nil {
+		return nil, err
+	}
+	since := int64(-1)
+	if blockHash != "" {
+		for _, b := range s.blocks {
+			if b.hash == blockHash {
+				since = b.height
+			}
+		}
+		if since < 0 {
+			return nil, rpcError(rpc.ErrCodeInvalidAddress, "Block not found")
+		}
+	}
+	return rpc.ListSinceBlockResult{
+		Transactions: s.walletTransactions(w, "*", func(t *tx) bool { return t.height < 0 || t.height > since }),
+		Removed:      []rpc.WalletTransaction{},
+		LastBlock:    s.tip().hash,
+	}, nil
+}
+
+// walletTransactions 按时间从旧到新返回钱包中满足 include 的交易的 send/receive 输出，label 为 "*" 时不按标签过滤
+func (s *Server) walletTransactions(w *wallet, label string, include func(*tx) bool) []rpc.WalletTransaction {
+	var txs []*tx
+	for _, t := range s.txs {
+		if s.involves(w, t) && include(t) {
+			txs = append(txs, t)
+		}
+	}
+	sort.Slice(txs, func(i, j int) bool {
+		if txs[i].time != txs[j].time {
+			return txs[i].time < txs[j].time
+		}
+		return txs[i].txid < txs[j].txid
+	})
+	entries := []rpc.WalletTransaction{}
+	for _, t := range txs {
+		for vout, o := range t.outputs {
+			mine := s.owners[o.address] == w
+			detail := rpc.TransactionDetail{Address: o.address, Vout: uint32(vout)}
+			switch {
+			case t.wallet == w.name && !mine:
+				detail.Category, detail.Amount, detail.Fee = "send", -toBTC(o.amount), -toBTC(t.fee)
+			case t.wallet != w.name && mine:
+				detail.Category, detail.Amount, detail.Label = "receive", toBTC(o.amount), w.labels[o.address]
+			default:
+				continue
+			}
+			if label != "*" && detail.Label != label {
+				continue
+			}
+			entries = append(entries, rpc.WalletTransaction{
+				TransactionDetail: detail,
+				Confirmations:     s.confirmations(t),
+				TxID:              t.txid,
+				Time:              t.time,
+				Comment:           t.comment,
+				ReplacedByTxID:    t.replacedBy,
+			})
+		}
+	}
+	return entries
+}
+
+func (s *Server) lockUnspent(walletName string, params []json.RawMessage) (interface{}, error) {
+	var unlock bool
+	var outputs []rpc.TxInput
+	if err := args(params, &unlock, &outputs); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := s.wallet(walletName)
+	if err != nil {
+		return nil, err
+	}
+	if unlock && len(outputs) == 0 {
+		for op := range s.locked {
+			if o, ok := s.output(op); ok && s.owners[o.address] == w {
+				delete(s.locked, op)
+			}
+		}
+		return true, nil
+	}
+	// 先检查全部输出，有一个无效时都不改变
+	for _, in := range outputs {
+		op := outpoint{txid: in.TxID, vout: int(in.Vout)}
+		o, ok := s.output(op)
+		switch {
+		case !ok || s.owners[o.address] != w:
+			return nil, rpcError(rpc.ErrCodeInvalidParameter, "Invalid parameter, unknown transaction")
+		case s.spender(op) != nil:
+			return nil, rpcError(rpc.ErrCodeInvalidParameter, "Invalid parameter, expected unspent output")
+		case unlock && !s.locked[op]:
+			return nil, rpcError(rpc.ErrCodeInvalidParameter, "Invalid parameter, expected locked output")
+		case !unlock && s.locked[op]:
+			return nil, rpcError(rpc.ErrCodeInvalidParameter, "Invalid parameter, output already locked")
+		}
+	}
+	for _, in := range outputs {
+		op := outpoint{txid: in.TxID, vout: int(in.Vout)}
+		if unlock {
+			delete(s.locked, op)
+		} else {
+			s.locked[op] = true
+		}
+	}
+	return true, nil
+}
+
+func (s *Server) listLockUnspent(walletName string, _ []json.RawMessage) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := s.wallet(walletName)
+	if err != nil {
+		return nil, err
+	}
+	locked := []rpc.TxInput{}
+	for op := range s.locked {
+		if o, ok := s.output(op); ok && s.owners[o.address] == w {
+			locked = append(locked, rpc.TxInput{TxID: op.txid, Vout: uint32(op.vout)})
+		}
+	}
+	sort.Slice(locked, func(i, j int) bool {
+		if locked[i].TxID != locked[j].TxID {
+			return locked[i].TxID < locked[j].TxID
+		}
+		return locked[i].Vout < locked[j].Vout
+	})
+	return locked, nil
+}
+
+// involves 返回交易是否由钱包发出或付款给钱包
+func (s *Server) involves(w *wallet, t *tx) bool {
+	if t.wallet == w.name {
+		return true
+	}
+	for _, o := range t.outputs {
+		if s.owners[o.address] == w {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) sendMany(walletName string, params []json.RawMessage) (interface{}, error) {
+	var (
+		dummy           string
+		amounts         map[string]float64
+		minconf         int64
+		comment         string
+		subtractFeeFrom []string
+		replaceable     = true
+		confTarget      int
+		estimateMode    string
+		feeRate         float64
+		verbose         bool
+	)
+	if err := args(params, &dummy, &amounts, &minconf, &comment, &subtractFeeFrom, &replaceable, &confTarget, &estimateMode, &feeRate, &verbose); err != nil {
+		return nil, err
+	}
+	if dummy != "" {
+		return nil, rpcError(rpc.ErrCodeInvalidParameter, "Dummy value must be set to \"\"")
+	}
+	if len(amounts) == 0 {
+		return nil, rpcError(rpc.ErrCodeInvalidParameter, "Invalid parameter, at least one recipient is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := s.wallet(walletName)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := make([]string, 0, len(amounts))
+	for address := range amounts {
+		recipients = append(recipients, address)
+	}
+	sort.Strings(recipients)
+	var outputs []output
+	var total int64
+	for _, address := range recipients {
+		if !validAddress(address) {
+			return nil, rpcError(rpc.ErrCodeInvalidAddress, "Invalid BitcoinPoW address: %s", address)
+		}
+		amount := toSat(amounts[address])
+		if amount <= 0 {
+			return nil, rpcError(rpc.ErrCodeTypeError, "Invalid amount for send")
+		}
+		outputs = append(outputs, output{address: address, amount: amount})
+		total += amount
+	}
+	for _, address := range subtractFeeFrom {
+		if _, ok := amounts[address]; !ok {
+			return nil, rpcError(rpc.ErrCodeInvalidParameter, "Invalid parameter 'subtract fee from output', address not found in outputs: %s", address)
+		}
+	}
+
+	rate, feeReason := s.walletFeeRate(feeRate, confTarget)
+
+	// 从确认数最多的 UTXO 开始选取，直到覆盖付款金额和手续费
+	var inputs []outpoint
+	var in int64
+	fee := int64(0)
+	for _, c := range s.coins(w) {
+		if s.confirmations(c.tx) < minconf || !s.trusted(w, c) || s.locked[c.outpoint] {
+			continue
+		}
+		inputs = append(inputs, c.outpoint)
+		in += c.amount
+		fee = rate * int64(txVSize(len(inputs), len(outputs)+1))
+		need := total + fee
+		if len(subtractFeeFrom) > 0 {
+			need = total
+		}
+		if in >= need {
+			break
+		}
+	}
+	need := total + fee
+	if len(subtractFeeFrom) > 0 {
+		need = total
+	}
+	if len(inputs) == 0 || in < need {
+		return nil, rpcError(rpc.ErrCodeInsufficientFunds, "Insufficient funds")
+	}
+
+	if len(subtractFeeFrom) > 0 {
+		share := fee / int64(len(subtractFeeFrom))
+		extra := fee - share*int64(len(subtractFeeFrom))
+		for i := range outputs {
+			if contains(subtractFeeFrom, outputs[i].address) {
+				outputs[i].amount -= share + extra
+				extra = 0
+				if outputs[i].amount <= dustLimit {
+					return nil, rpcError(rpc.ErrCodeWallet, "The transaction amount is too small to pay the fee")
+				}
+			}
+		}
+		total -= fee
+	}
+	vsize := txVSize(len(inputs), len(outputs))
+	if change := in - total - fee; change > dustLimit {
+		outputs = append(outputs, output{address: s.newAddress(w, "", true), amount: change})
+		vsize = txVSize(len(inputs), len(outputs))
+	} else {
+		fee = in - total
+	}
+	if fee > maxTxFee {
+		return nil, rpcError(rpc.ErrCodeWallet, "Fee exceeds maximum configured by user (e.g. -maxtxfee, maxfeerate)")
+	}
+	if vsize > maxStandardTxVSize {
+		return nil, rpcError(rpc.ErrCodeWallet, "Transaction too large")
+	}
+
+	t := &tx{
+		txid:        s.newID(),
+		wallet:      w.name,
+		inputs:      inputs,
+		outputs:     outputs,
+		fee:         fee,
+		vsize:       vsize,
+		height:      -1,
+		replaceable: replaceable,
+		time:        s.tip().time,
+		comment:     comment,
+	}
+	if err := s.checkChainLimits(t); err != nil {
+		return nil, err
+	}
+	s.addTx(t)
+	if verbose {
+		return rpc.SendManyResult{TxID: t.txid, FeeReason: feeReason}, nil
+	}
+	return t.txid, nil
+}
+
+func (s *Server) bumpFee(walletName string, params []json.RawMessage) (interface{}, error) {
+	var txid string
+	var opts rpc.BumpFeeOptions
+	if err := args(params, &txid, &opts); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := s.wallet(walletName)
+	if err != nil {
+		return nil, err
+	}
+	old, ok := s.txs[txid]
+	if !ok || old.wallet != w.name {
+		return nil, rpcError(rpc.ErrCodeInvalidAddress, "Invalid or non-wallet transaction id")
+	}
+	switch {
+	case old.replacedBy != "":
+		return nil, rpcError(rpc.ErrCodeInvalidParameter, "Cannot bump transaction %s which was already bumped by transaction %s", txid, old.replacedBy)
+	case !s.mempool[txid]:
+		return nil, rpcError(rpc.ErrCodeWallet, "Transaction has been mined, or is conflicted with a mined transaction")
+	case !old.replaceable:
+		return nil, rpcError(rpc.ErrCodeWallet, "Transaction is not BIP 125 replaceable")
+	case len(s.descendants(old)) > 0:
+		return nil, rpcError(rpc.ErrCodeWallet, "Transaction has descendants in the wallet")
+	}
+
+	// 未指定费率时按原费率加 1 sat/vB
+	newFee := old.fee + int64(old.vsize)
+	if opts.FeeRate > 0 {
+		newFee = int64(math.Ceil(opts.FeeRate * float64(old.vsize)))
+	}
+	if minFee := old.fee + int64(old.vsize); newFee < minFee {
+		return nil, rpcError(rpc.ErrCodeInvalidParameter, "Insufficient total fee %.8f, must be at least %.8f (oldFee %.8f + incrementalFee %.8f)",
+			toBTC(newFee), toBTC(minFee), toBTC(old.fee), toBTC(int64(old.vsize)))
+	}
+	if newFee > maxTxFee {
+		return nil, rpcError(rpc.ErrCodeWallet, "Specified or calculated fee %.8f is too high (cannot be higher than -maxtxfee %.8f)", toBTC(newFee), toBTC(maxTxFee))
+	}
+
+	outputs := append([]output(nil), old.outputs...)
+	changeIndex := -1
+	for i, o := range outputs {
+		if w.change[o.address] {
+			changeIndex = i
+		}
+	}
+	delta := newFee - old.fee
+	if changeIndex < 0 || outputs[changeIndex].amount-delta <= dustLimit {
+		return nil, rpcError(rpc.ErrCodeWallet, "Unable to create transaction. Change output is too small to bump the fee")
+	}
+	outputs[changeIndex].amount -= delta
+
+	replacement := &tx{
+		txid:        s.newID(),
+		wallet:      w.name,
+		inputs:      old.inputs,
+		outputs:     outputs,
+		fee:         newFee,
+		vsize:       old.vsize,
+		height:      -1,
+		replaceable: true,
+		replaces:    old.txid,
+		time:        s.tip().time,
+		comment:     old.comment,
+	}
+	if opts.Replaceable != nil {
+		replacement.replaceable = *opts.Replaceable
+	}
+	old.replacedBy = replacement.txid
+	delete(s.mempool, old.txid)
+	s.addTx(replacement)
+	return rpc.BumpFeeResult{TxID: replacement.txid, OrigFee: toBTC(old.fee), Fee: toBTC(newFee), Errors: []string{}}, nil
+}
+
+// validAddress 粗略检查地址格式，只接受字母和数字
+func validAddress(address string) bool {
+	if len(address) < 14 {
+		return false
+	}
+	for _, r := range address {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// walletFeeRate 返回钱包交易的费率（sat/vB）和 fee_reason：指定了 fee_rate 时使用它，
+// 指定了 conf_target 且有估算时使用 estimatesmartfee 的费率，否则使用默认费率。调用者持有 s.mu
+func (s *Server) walletFeeRate(feeRate float64, confTarget int) (int64, string) {
+	switch {
+	case feeRate > 0:
+		return int64(math.Ceil(feeRate)), "User-specified feerate"
+	case confTarget > 0 && s.smartFee > 0:
+		return s.smartFee, "Double Target 95% Threshold"
+	}
+	return s.defaultFee, "Fallback fee"
+}
@@ -0,0 +1,67 @@
+package rpctest
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+
+	"address/zmq"
+)
+
+// ZMQ 启动模拟节点的 ZMQ 发布端并返回其 tcp:// 端点。之后产生的区块和进入交易池的交易
+// 分别以 hashblock 以及 rawtx 和 hashtx 通知发布，相当于 bitcoind 的 -zmqpubhashblock、-zmqpubrawtx 和 -zmqpubhashtx 使用同一端点
+func (s *Server) ZMQ() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.publisher == nil {
+		p, err := zmq.Listen("127.0.0.1:0")
+		if err != nil {
+			panic("rpctest: starting ZMQ publisher: " + err.Error())
+		}
+		s.publisher = p
+		s.sequences = make(map[string]uint32)
+	}
+	return s.publisher.Endpoint()
+}
+
+// ZMQSubscribers 返回订阅了主题 topic 的订阅者数量，测试可据此等待订阅者就绪
+func (s *Server) ZMQSubscribers(topic string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.publisher == nil {
+		return 0
+	}
+	return s.publisher.Subscribers(topic)
+}
+
+// Close 关闭 HTTP 服务和 ZMQ 发布端
+func (s *Server) Close() {
+	s.mu.Lock()
+	if s.publisher != nil {
+		s.publisher.Close()
+	}
+	s.mu.Unlock()
+	s.Server.Close()
+}
+
+// notify 发布 bitcoind 格式的通知：主题、内容和小端序的 4 字节序号，调用者需持有 s.mu
+func (s *Server) notify(topic string, body []byte) {
+	if s.publisher == nil {
+		return
+	}
+	seq := binary.LittleEndian.AppendUint32(nil, s.sequences[topic])
+	s.sequences[topic]++
+	s.publisher.Publish([]byte(topic), body, seq)
+}
+
+// notifyBlock 发布 hashblock 通知
+func (s *Server) notifyBlock(b *block) {
+	hash, _ := hex.DecodeString(b.hash)
+	s.notify("hashblock", hash)
+}
+
+// notifyTx 发布 rawtx 和 hashtx 通知。模拟节点的交易没有真实的序列化，rawtx 的内容也是 txid
+func (s *Server) notifyTx(t *tx) {
+	hash, _ := hex.DecodeString(t.txid)
+	s.notify("rawtx", hash)
+	s.notify("hashtx", hash)
+}
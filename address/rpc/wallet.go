@@ -0,0 +1,398 @@
+package rpc
+
+import "context"
+
+// Unspent 是 listunspent 返回的一个 UTXO
+type Unspent struct {
+	TxID          string  `json:"txid"`
+	Vout          uint32  `json:"vout"`
+	Address       string  `json:"address"`
+	Label         string  `json:"label"`
+	ScriptPubKey  string  `json:"scriptPubKey"`
+	Amount        float64 `json:"amount"`
+	Confirmations int64   `json:"confirmations"`
+	AncestorCount int     `json:"ancestorcount"`
+	AncestorSize  int     `json:"ancestorsize"`
+	AncestorFees  int64   `json:"ancestorfees"`
+	Spendable     bool    `json:"spendable"`
+	Solvable      bool    `json:"solvable"`
+	Desc          string  `json:"desc"`
+	Safe          bool    `json:"safe"`
+}
+
+// ListUnspentOptions 对应 listunspent 的 query_options
+type ListUnspentOptions struct {
+	MinimumAmount    float64 `json:"minimumAmount,omitempty"`
+	MaximumAmount    float64 `json:"maximumAmount,omitempty"`
+	MaximumCount     int     `json:"maximumCount,omitempty"`
+	MinimumSumAmount float64 `json:"minimumSumAmount,omitempty"`
+}
+
+// Balance 是 getbalances 中 mine/watchonly 的余额明细
+type Balance struct {
+	Trusted          float64  `json:"trusted"`
+	UntrustedPending float64  `json:"untrusted_pending"`
+	Immature         float64  `json:"immature"`
+	Used             *float64 `json:"used,omitempty"`
+}
+
+// Balances 是 getbalances 的结果
+type Balances struct {
+	Mine      Balance  `json:"mine"`
+	WatchOnly *Balance `json:"watchonly,omitempty"`
+}
+
+// TransactionDetail 是 gettransaction 中 details 的一项
+type TransactionDetail struct {
+	Address   string  `json:"address"`
+	Category  string  `json:"category"`
+	Amount    float64 `json:"amount"`
+	Label     string  `json:"label"`
+	Vout      uint32  `json:"vout"`
+	Fee       float64 `json:"fee"`
+	Abandoned bool    `json:"abandoned"`
+}
+
+// Transaction 是 gettransaction 的结果，Fee 仅对本钱包发出的交易有值且为负数
+type Transaction struct {
+	Amount            float64             `json:"amount"`
+	Fee               float64             `json:"fee"`
+	Confirmations     int64               `json:"confirmations"`
+	BlockHash         string              `json:"blockhash"`
+	BlockHeight       int64               `json:"blockheight"`
+	TxID              string              `json:"txid"`
+	WalletConflicts   []string            `json:"walletconflicts"`
+	ReplacedByTxID    string              `json:"replaced_by_txid"`
+	ReplacesTxID      string              `json:"replaces_txid"`
+	Time              int64               `json:"time"`
+	TimeReceived      int64               `json:"timereceived"`
+	BIP125Replaceable string              `json:"bip125-replaceable"`
+	Details           []TransactionDetail `json:"details"`
+	Hex               string              `json:"hex"`
+}
+
+// WalletTransaction 是 listtransactions 返回的一项，交易的每个 send/receive 输出各一项
+type WalletTransaction struct {
+	TransactionDetail
+	Confirmations  int64  `json:"confirmations"`
+	TxID           string `json:"txid"`
+	Time           int64  `json:"time"`
+	Comment        string `json:"comment"`
+	ReplacedByTxID string `json:"replaced_by_txid"`
+}
+
+// ListSinceBlockResult 是 listsinceblock 的结果
+type ListSinceBlockResult struct {
+	Transactions []WalletTransaction `json:"transactions"`
+	// Removed 是因链重组不再在主链上的交易
+	Removed   []WalletTransaction `json:"removed"`
+	LastBlock string              `json:"lastblock"`
+}
+
+// SendManyOptions 对应 sendmany 除 amounts 以外的位置参数，零值表示使用节点默认值
+type SendManyOptions struct {
+	Minconf         int
+	Comment         string
+	SubtractFeeFrom []string
+	Replaceable     *bool
+	ConfTarget      int
+	EstimateMode    string
+	FeeRate         float64 // sat/vB
+}
+
+// SendManyResult 是 verbose=true 时 sendmany 的结果
+type SendManyResult struct {
+	TxID      string `json:"txid"`
+	FeeReason string `json:"fee_reason"`
+}
+
+// BumpFeeOptions 对应 bumpfee 的 options
+type BumpFeeOptions struct {
+	ConfTarget   int     `json:"conf_target,omitempty"`
+	FeeRate      float64 `json:"fee_rate,omitempty"` // sat/vB
+	Replaceable  *bool   `json:"replaceable,omitempty"`
+	EstimateMode string  `json:"estimate_mode,omitempty"`
+}
+
+// BumpFeeResult 是 bumpfee 的结果
+type BumpFeeResult struct {
+	TxID    string   `json:"txid"`
+	OrigFee float64  `json:"origfee"`
+	Fee     float64  `json:"fee"`
+	Errors  []string `json:"errors"`
+}
+
+// ReceivedByAddress 是 listreceivedbyaddress 返回的一项，也是 newaddress 输出的 JSON 格式
+type ReceivedByAddress struct {
+	InvolvesWatchonly bool     `json:"involvesWatchonly,omitempty"`
+	Address           string   `json:"address"`
+	Amount            float64  `json:"amount"`
+	Confirmations     int      `json:"confirmations"`
+	Label             string   `json:"label"`
+	Txids             []string `json:"txids"`
+}
+
+// SignRawTransactionError 是 signrawtransactionwithwallet 中无法签名的输入
+type SignRawTransactionError struct {
+	TxID      string `json:"txid"`
+	Vout      uint32 `json:"vout"`
+	ScriptSig string `json:"scriptSig"`
+	Sequence  uint32 `json:"sequence"`
+	Error     string `json:"error"`
+}
+
+// SignRawTransactionResult 是 signrawtransactionwithwallet 的结果
+type SignRawTransactionResult struct {
+	Hex      string                    `json:"hex"`
+	Complete bool                      `json:"complete"`
+	Errors   []SignRawTransactionError `json:"errors"`
+}
+
+// FundRawTransactionOptions 对应 fundrawtransaction 的 options，零值表示使用钱包默认值
+type FundRawTransactionOptions struct {
+	// AddInputs 为 false 时只使用交易中已有的输入，不足时返回资金不足
+	AddInputs      *bool   `json:"add_inputs,omitempty"`
+	ChangeAddress  string  `json:"changeAddress,omitempty"`
+	ChangePosition *int    `json:"changePosition,omitempty"`
+	LockUnspents   bool    `json:"lockUnspents,omitempty"`
+	FeeRate        float64 `json:"fee_rate,omitempty"` // sat/vB
+	Replaceable    *bool   `json:"replaceable,omitempty"`
+	ConfTarget     int     `json:"conf_target,omitempty"`
+	EstimateMode   string  `json:"estimate_mode,omitempty"`
+}
+
+// FundRawTransactionResult 是 fundrawtransaction 的结果，ChangePos 为 -1 时没有找零
+type FundRawTransactionResult struct {
+	Hex       string  `json:"hex"`
+	Fee       float64 `json:"fee"`
+	ChangePos int     `json:"changepos"`
+}
+
+// WalletCreateFundedPSBTResult 是 walletcreatefundedpsbt 的结果，PSBT 为 base64 编码
+type WalletCreateFundedPSBTResult struct {
+	PSBT      string  `json:"psbt"`
+	Fee       float64 `json:"fee"`
+	ChangePos int     `json:"changepos"`
+}
+
+// WalletProcessPSBTResult 是 walletprocesspsbt 的结果，Complete 为 true 时所有输入都已签名
+type WalletProcessPSBTResult struct {
+	PSBT     string `json:"psbt"`
+	Complete bool   `json:"complete"`
+}
+
+// CreateWalletOptions 对应 createwallet 除钱包名以外的位置参数
+type CreateWalletOptions struct {
+	DisablePrivateKeys bool
+	Blank              bool
+	Passphrase         string
+	AvoidReuse         bool
+	Descriptors        bool
+	LoadOnStartup      bool
+}
+
+// CreateWalletResult 是 createwallet 的结果
+type CreateWalletResult struct {
+	Name     string   `json:"name"`
+	Warning  string   `json:"warning"`
+	Warnings []string `json:"warnings"`
+}
+
+// ListWallets 返回节点已加载的钱包
+func (c *Client) ListWallets(ctx context.Context) ([]string, error) {
+	var wallets []string
+	err := c.Call(ctx, "listwallets", &wallets)
+	return wallets, err
+}
+
+// ListUnspent 列出确认数介于 [minconf, maxconf] 的 UTXO，opts 可为 nil
+func (c *Client) ListUnspent(ctx context.Context, minconf, maxconf int, addresses []string, includeUnsafe bool, opts *ListUnspentOptions) ([]Unspent, error) {
+	if addresses == nil {
+		addresses = []string{}
+	}
+	params := []interface{}{minconf, maxconf, addresses, includeUnsafe}
+	if opts != nil {
+		params = append(params, opts)
+	}
+	var unspent []Unspent
+	err := c.Call(ctx, "listunspent", &unspent, params...)
+	return unspent, err
+}
+
+// GetBalances 返回钱包余额
+func (c *Client) GetBalances(ctx context.Context) (*Balances, error) {
+	var balances Balances
+	if err := c.Call(ctx, "getbalances", &balances); err != nil {
+		return nil, err
+	}
+	return &balances, nil
+}
+
+// GetTransaction 返回钱包内交易的详情
+func (c *Client) GetTransaction(ctx context.Context, txid string) (*Transaction, error) {
+	var tx Transaction
+	if err := c.Call(ctx, "gettransaction", &tx, txid); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// ListTransactions 返回钱包最近的 count 项交易记录，跳过最新的 skip 项，按时间从旧到新排列。
+// label 为 "*" 时返回所有标签
+func (c *Client) ListTransactions(ctx context.Context, label string, count, skip int) ([]WalletTransaction, error) {
+	var txs []WalletTransaction
+	err := c.Call(ctx, "listtransactions", &txs, label, count, skip)
+	return txs, err
+}
+
+// ListSinceBlock 返回区块 blockHash 之后确认的和尚未确认的钱包交易，每个 send/receive 输出各一项。
+// blockHash 为空时返回钱包的全部交易
+func (c *Client) ListSinceBlock(ctx context.Context, blockHash string) (*ListSinceBlockResult, error) {
+	var params []interface{}
+	if blockHash != "" {
+		params = append(params, blockHash)
+	}
+	var result ListSinceBlockResult
+	if err := c.Call(ctx, "listsinceblock", &result, params...); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// LockUnspent 锁定（unlock 为 false）或解锁钱包的未花费输出，锁定的输出不会被自动选为输入。
+// 锁定只保存在节点内存中，节点重启后解除；unlock 为 true 且 outputs 为空时解锁全部输出
+func (c *Client) LockUnspent(ctx context.Context, unlock bool, outputs []TxInput) error {
+	if outputs == nil {
+		outputs = []TxInput{}
+	}
+	var ok bool
+	return c.Call(ctx, "lockunspent", &ok, unlock, outputs)
+}
+
+// ListLockUnspent 返回钱包中锁定的输出
+func (c *Client) ListLockUnspent(ctx context.Context) ([]TxInput, error) {
+	var outputs []TxInput
+	err := c.Call(ctx, "listlockunspent", &outputs)
+	return outputs, err
+}
+
+// SendMany 向多个地址付款，amounts 为 地址 -> BTCW 数量
+func (c *Client) SendMany(ctx context.Context, amounts map[string]float64, opts SendManyOptions) (*SendManyResult, error) {
+	subtractFeeFrom := opts.SubtractFeeFrom
+	if subtractFeeFrom == nil {
+		subtractFeeFrom = []string{}
+	}
+	params := []interface{}{"", amounts, opts.Minconf, opts.Comment, subtractFeeFrom, nil, nil, nil, nil, true}
+	if opts.Replaceable != nil {
+		params[5] = *opts.Replaceable
+	}
+	if opts.ConfTarget > 0 {
+		params[6] = opts.ConfTarget
+	}
+	if opts.EstimateMode != "" {
+		params[7] = opts.EstimateMode
+	}
+	if opts.FeeRate > 0 {
+		params[8] = opts.FeeRate
+	}
+	var result SendManyResult
+	if err := c.Call(ctx, "sendmany", &result, params...); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// BumpFee 用 RBF 替换钱包内的未确认交易，opts 可为 nil
+func (c *Client) BumpFee(ctx context.Context, txid string, opts *BumpFeeOptions) (*BumpFeeResult, error) {
+	params := []interface{}{txid}
+	if opts != nil {
+		params = append(params, opts)
+	}
+	var result BumpFeeResult
+	if err := c.Call(ctx, "bumpfee", &result, params...); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetNewAddress 生成新地址
+func (c *Client) GetNewAddress(ctx context.Context, label, addressType string) (string, error) {
+	var address string
+	err := c.Call(ctx, "getnewaddress", &address, label, addressType)
+	return address, err
+}
+
+// ListReceivedByAddress 列出地址收款情况
+func (c *Client) ListReceivedByAddress(ctx context.Context, minconf int, includeEmpty bool) ([]ReceivedByAddress, error) {
+	var received []ReceivedByAddress
+	err := c.Call(ctx, "listreceivedbyaddress", &received, minconf, includeEmpty)
+	return received, err
+}
+
+// CreateWallet 创建并加载新钱包
+func (c *Client) CreateWallet(ctx context.Context, name string, opts CreateWalletOptions) (*CreateWalletResult, error) {
+	var result CreateWalletResult
+	err := c.Call(ctx, "createwallet", &result, name, opts.DisablePrivateKeys, opts.Blank, opts.Passphrase, opts.AvoidReuse, opts.Descriptors, opts.LoadOnStartup)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetRawChangeAddress 生成用于找零的新地址，addressType 为空时使用钱包的 -changetype
+func (c *Client) GetRawChangeAddress(ctx context.Context, addressType string) (string, error) {
+	var params []interface{}
+	if addressType != "" {
+		params = append(params, addressType)
+	}
+	var address string
+	err := c.Call(ctx, "getrawchangeaddress", &address, params...)
+	return address, err
+}
+
+// SignRawTransactionWithWallet 用钱包私钥签名交易
+func (c *Client) SignRawTransactionWithWallet(ctx context.Context, hex string) (*SignRawTransactionResult, error) {
+	var result SignRawTransactionResult
+	if err := c.Call(ctx, "signrawtransactionwithwallet", &result, hex); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// FundRawTransaction 为交易补充输入、计算手续费并添加找零，返回未签名的交易
+func (c *Client) FundRawTransaction(ctx context.Context, hex string, opts *FundRawTransactionOptions) (*FundRawTransactionResult, error) {
+	params := []interface{}{hex}
+	if opts != nil {
+		params = append(params, opts)
+	}
+	var result FundRawTransactionResult
+	if err := c.Call(ctx, "fundrawtransaction", &result, params...); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// WalletCreateFundedPSBT 创建并资助交易，返回未签名的 PSBT。watch-only 钱包也可以调用，
+// 签名由持有私钥的钱包完成；opts 与 fundrawtransaction 的选项相同
+func (c *Client) WalletCreateFundedPSBT(ctx context.Context, inputs []TxInput, outputs map[string]float64, opts *FundRawTransactionOptions) (*WalletCreateFundedPSBTResult, error) {
+	if inputs == nil {
+		inputs = []TxInput{}
+	}
+	if opts == nil {
+		opts = &FundRawTransactionOptions{}
+	}
+	var result WalletCreateFundedPSBTResult
+	if err := c.Call(ctx, "walletcreatefundedpsbt", &result, inputs, outputs, 0, opts); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// WalletProcessPSBT 用钱包补充 PSBT 的输入信息，sign 为 true 时用钱包私钥签名
+func (c *Client) WalletProcessPSBT(ctx context.Context, psbt string, sign bool) (*WalletProcessPSBTResult, error) {
+	var result WalletProcessPSBTResult
+	if err := c.Call(ctx, "walletprocesspsbt", &result, psbt, sign); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
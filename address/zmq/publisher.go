@@ -0,0 +1,166 @@
+package zmq
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"sync"
+)
+
+// Publisher 是监听本地端口的 PUB 套接字，用于在测试中代替节点的 zmqpub* 端点
+type Publisher struct {
+	ln    net.Listener
+	mu    sync.Mutex
+	conns map[*pubConn]bool
+	ready chan struct{} // 每当有订阅者发来订阅时通知
+}
+
+type pubConn struct {
+	conn   net.Conn
+	topics map[string]bool
+}
+
+// Listen 在 addr（如 127.0.0.1:0）上监听订阅者
+func Listen(addr string) (*Publisher, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	p := &Publisher{ln: ln, conns: make(map[*pubConn]bool), ready: make(chan struct{}, 1)}
+	go p.accept()
+	return p, nil
+}
+
+// Endpoint 返回 tcp://host:port 形式的端点
+func (p *Publisher) Endpoint() string {
+	return "tcp://" + p.ln.Addr().String()
+}
+
+func (p *Publisher) accept() {
+	for {
+		conn, err := p.ln.Accept()
+		if err != nil {
+			return
+		}
+		go p.serve(conn)
+	}
+}
+
+// serve 完成握手并读取订阅者的订阅
+func (p *Publisher) serve(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	if peer, err := handshake(conn, r, "PUB"); err != nil || (peer != "SUB" && peer != "XSUB") {
+		conn.Close()
+		return
+	}
+	c := &pubConn{conn: conn, topics: make(map[string]bool)}
+	p.mu.Lock()
+	p.conns[c] = true
+	p.mu.Unlock()
+	defer p.drop(c)
+	for {
+		flags, body, err := readFrame(r)
+		if err != nil {
+			return
+		}
+		var subscribe bool
+		var topic string
+		switch {
+		case flags&flagCommand != 0:
+			// ZMTP 3.1 用 SUBSCRIBE/CANCEL 命令订阅
+			name, data, err := parseCommand(body)
+			if err != nil || (name != "SUBSCRIBE" && name != "CANCEL") {
+				continue
+			}
+			subscribe, topic = name == "SUBSCRIBE", string(data)
+		case len(body) > 0 && body[0] <= 1:
+			subscribe, topic = body[0] == 1, string(body[1:])
+		default:
+			continue
+		}
+		p.mu.Lock()
+		if subscribe {
+			c.topics[topic] = true
+		} else {
+			delete(c.topics, topic)
+		}
+		p.mu.Unlock()
+		select {
+		case p.ready <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (p *Publisher) drop(c *pubConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.conns, c)
+	c.conn.Close()
+}
+
+// Subscribed 返回一个通道，有订阅者发来订阅时可读，测试可据此等待订阅者就绪
+func (p *Publisher) Subscribed() <-chan struct{} {
+	return p.ready
+}
+
+// Subscribers 返回会收到主题 topic 的消息的订阅者数量
+func (p *Publisher) Subscribers(topic string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for c := range p.conns {
+		if c.matches([]byte(topic)) {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *pubConn) matches(topic []byte) bool {
+	for t := range c.topics {
+		if bytes.HasPrefix(topic, []byte(t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish 把多帧消息发送给订阅了匹配主题的订阅者，第一帧为主题，写入失败的订阅者被断开
+func (p *Publisher) Publish(frames ...[]byte) error {
+	if len(frames) == 0 {
+		return errors.New("empty message")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for c := range p.conns {
+		if !c.matches(frames[0]) {
+			continue
+		}
+		for i, frame := range frames {
+			var flags byte
+			if i < len(frames)-1 {
+				flags = flagMore
+			}
+			if err := writeFrame(c.conn, flags, frame); err != nil {
+				delete(p.conns, c)
+				c.conn.Close()
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// Close 停止监听并断开所有订阅者
+func (p *Publisher) Close() error {
+	err := p.ln.Close()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for c := range p.conns {
+		c.conn.Close()
+		delete(p.conns, c)
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package zmq
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Subscriber 是连接到一个 PUB 端点的 SUB 套接字，不会自动重连
+type Subscriber struct {
+	conn net.Conn
+	r    *bufio.Reader
+	mu   sync.Mutex // 保护写入
+}
+
+// Dial 连接 tcp://host:port 形式的 PUB 端点并订阅 topics，topics 为空时订阅全部消息
+func Dial(ctx context.Context, endpoint string, topics ...string) (*Subscriber, error) {
+	addr, err := address(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Subscriber{conn: conn, r: bufio.NewReader(conn)}
+	peer, err := handshake(conn, s.r, "SUB")
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("zmq handshake with %s: %w", endpoint, err)
+	}
+	if peer != "PUB" && peer != "XPUB" {
+		conn.Close()
+		return nil, fmt.Errorf("zmq endpoint %s is a %s socket, want PUB", endpoint, peer)
+	}
+	if len(topics) == 0 {
+		topics = []string{""}
+	}
+	for _, topic := range topics {
+		if err := s.Subscribe(topic); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Subscribe 订阅以 topic 开头的消息
+func (s *Subscriber) Subscribe(topic string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// ZMTP 3.0 的订阅是以 0x01 开头的消息
+	return writeFrame(s.conn, 0, append([]byte{1}, topic...))
+}
+
+// Recv 阻塞直到收到一条消息，返回其全部帧，连接断开或 Close 后返回错误
+func (s *Subscriber) Recv() ([][]byte, error) {
+	var frames [][]byte
+	for {
+		flags, body, err := readFrame(s.r)
+		if err != nil {
+			return nil, err
+		}
+		// 忽略 PING 等命令
+		if flags&flagCommand != 0 {
+			continue
+		}
+		frames = append(frames, body)
+		if flags&flagMore == 0 {
+			return frames, nil
+		}
+	}
+}
+
+// Close 关闭连接，阻塞中的 Recv 会返回错误
+func (s *Subscriber) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,92 @@
+package zmq
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+// waitSubscribers 等待 n 个订阅者订阅主题 topic
+func waitSubscribers(t *testing.T, p *Publisher, topic string, n int) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for p.Subscribers(topic) < n {
+		select {
+		case <-p.Subscribed():
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatalf("timed out waiting for %d subscribers to %q", n, topic)
+		}
+	}
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	p, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	ctx := context.Background()
+
+	blocks, err := Dial(ctx, p.Endpoint(), "hashblock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blocks.Close()
+	all, err := Dial(ctx, p.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer all.Close()
+	waitSubscribers(t, p, "hashblock", 2)
+
+	// rawtx 只发给订阅全部消息的订阅者，大于 255 字节的帧使用长帧
+	rawtx := bytes.Repeat([]byte{0xab}, 300)
+	if err := p.Publish([]byte("rawtx"), rawtx, []byte{0, 0, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Publish([]byte("hashblock"), bytes.Repeat([]byte{1}, 32), []byte{7, 0, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := blocks.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg) != 3 || string(msg[0]) != "hashblock" || len(msg[1]) != 32 || msg[2][0] != 7 {
+		t.Fatalf("unexpected hashblock message %q", msg)
+	}
+	msg, err = all.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg[0]) != "rawtx" || !bytes.Equal(msg[1], rawtx) {
+		t.Fatalf("unexpected rawtx message %q", msg)
+	}
+	if msg, err = all.Recv(); err != nil || string(msg[0]) != "hashblock" {
+		t.Fatalf("Recv = %q, %v, want hashblock", msg, err)
+	}
+
+	// 发布端关闭后 Recv 返回错误
+	p.Close()
+	if _, err := blocks.Recv(); err == nil {
+		t.Fatal("want error after publisher closed")
+	}
+}
+
+func TestDialErrors(t *testing.T) {
+	ctx := context.Background()
+	if _, err := Dial(ctx, "127.0.0.1:28332"); err == nil {
+		t.Error("want error for endpoint without tcp://")
+	}
+	p, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := p.Endpoint()
+	p.Close()
+	if _, err := Dial(ctx, endpoint); err == nil {
+		t.Error("want error for closed endpoint")
+	}
+}
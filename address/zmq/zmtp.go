@@ -0,0 +1,200 @@
+// Package zmq 实现订阅 bitcoind ZMQ 通知所需的最小 ZMTP 3.0 协议：NULL 认证的 SUB 套接字，
+// 以及在测试中代替节点的 PUB 套接字
+package zmq
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// HandshakeTimeout 建立连接时交换问候和 READY 命令的超时
+const HandshakeTimeout = 10 * time.Second
+
+// 帧标志位
+const (
+	flagMore    = 0x01
+	flagLong    = 0x02
+	flagCommand = 0x04
+)
+
+// maxFrameSize 限制单帧大小，rawtx/rawblock 之外的通知都很小，区块最大 4MB
+const maxFrameSize = 16 << 20
+
+// greeting 返回 ZMTP 3.0 问候：签名、版本 3.0、NULL 认证、as-server 和填充，共 64 字节
+func greeting() []byte {
+	g := make([]byte, 64)
+	g[0] = 0xff
+	g[9] = 0x7f
+	g[10], g[11] = 3, 0
+	copy(g[12:32], "NULL")
+	return g
+}
+
+// readGreeting 读取并检查对端的问候
+func readGreeting(r io.Reader) error {
+	g := make([]byte, 64)
+	if _, err := io.ReadFull(r, g); err != nil {
+		return fmt.Errorf("reading greeting: %w", err)
+	}
+	if g[0] != 0xff || g[9]&0x01 == 0 {
+		return errors.New("peer is not a ZMTP 3 endpoint")
+	}
+	if g[10] < 3 {
+		return fmt.Errorf("unsupported ZMTP version %d.%d", g[10], g[11])
+	}
+	if mechanism := string(bytes.TrimRight(g[12:32], "\x00")); mechanism != "NULL" {
+		return fmt.Errorf("unsupported security mechanism %q", mechanism)
+	}
+	return nil
+}
+
+// writeFrame 写入一帧
+func writeFrame(w io.Writer, flags byte, body []byte) error {
+	var header []byte
+	if len(body) > 255 {
+		header = make([]byte, 9)
+		header[0] = flags | flagLong
+		binary.BigEndian.PutUint64(header[1:], uint64(len(body)))
+	} else {
+		header = []byte{flags, byte(len(body))}
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(body)
+	return err
+}
+
+// readFrame 读取一帧，返回标志位和内容
+func readFrame(r *bufio.Reader) (byte, []byte, error) {
+	flags, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	var size uint64
+	if flags&flagLong != 0 {
+		var b [8]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(b[:])
+	} else {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		size = uint64(b)
+	}
+	if size > maxFrameSize {
+		return 0, nil, fmt.Errorf("frame of %d bytes exceeds limit", size)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return flags, body, nil
+}
+
+// command 编码命令帧的内容：名称长度、名称和数据
+func command(name string, data []byte) []byte {
+	body := append([]byte{byte(len(name))}, name...)
+	return append(body, data...)
+}
+
+// parseCommand 解析命令帧的内容
+func parseCommand(body []byte) (string, []byte, error) {
+	if len(body) == 0 || int(body[0]) > len(body)-1 {
+		return "", nil, errors.New("malformed command frame")
+	}
+	n := int(body[0])
+	return string(body[1 : 1+n]), body[1+n:], nil
+}
+
+// readyCommand 编码带 Socket-Type 属性的 READY 命令
+func readyCommand(socketType string) []byte {
+	const name = "Socket-Type"
+	props := append([]byte{byte(len(name))}, name...)
+	props = binary.BigEndian.AppendUint32(props, uint32(len(socketType)))
+	props = append(props, socketType...)
+	return command("READY", props)
+}
+
+// parseReady 解析 READY 命令的属性，返回对端的套接字类型
+func parseReady(data []byte) (string, error) {
+	for len(data) > 0 {
+		n := int(data[0])
+		if len(data) < 1+n+4 {
+			return "", errors.New("malformed READY properties")
+		}
+		name := string(data[1 : 1+n])
+		data = data[1+n:]
+		size := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		if uint64(size) > uint64(len(data)) {
+			return "", errors.New("malformed READY properties")
+		}
+		if strings.EqualFold(name, "Socket-Type") {
+			return string(data[:size]), nil
+		}
+		data = data[size:]
+	}
+	return "", errors.New("READY without Socket-Type")
+}
+
+// handshake 在新连接上交换问候和 READY 命令，返回对端的套接字类型
+func handshake(conn net.Conn, r *bufio.Reader, socketType string) (string, error) {
+	conn.SetDeadline(time.Now().Add(HandshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+	if _, err := conn.Write(greeting()); err != nil {
+		return "", err
+	}
+	if err := readGreeting(r); err != nil {
+		return "", err
+	}
+	if err := writeFrame(conn, flagCommand, readyCommand(socketType)); err != nil {
+		return "", err
+	}
+	flags, body, err := readFrame(r)
+	if err != nil {
+		return "", fmt.Errorf("reading READY: %w", err)
+	}
+	if flags&flagCommand == 0 {
+		return "", errors.New("expected READY command")
+	}
+	name, data, err := parseCommand(body)
+	if err != nil {
+		return "", err
+	}
+	switch name {
+	case "READY":
+		return parseReady(data)
+	case "ERROR":
+		return "", fmt.Errorf("peer rejected handshake: %s", reason(data))
+	default:
+		return "", fmt.Errorf("expected READY command, got %q", name)
+	}
+}
+
+// reason 返回 ERROR 命令中的原因
+func reason(data []byte) string {
+	if len(data) == 0 || int(data[0]) > len(data)-1 {
+		return string(data)
+	}
+	return string(data[1 : 1+int(data[0])])
+}
+
+// address 把 tcp://host:port 形式的端点转换为 host:port
+func address(endpoint string) (string, error) {
+	addr, ok := strings.CutPrefix(endpoint, "tcp://")
+	if !ok {
+		return "", fmt.Errorf("unsupported endpoint %q, want tcp://host:port", endpoint)
+	}
+	return addr, nil
+}